@@ -1,27 +1,57 @@
 package goop
 
+import "math"
+
 // Constr represnts a linear constraint of the form x <= y, x >= y, or
 // x == y. Constr uses a left and right hand side expressions along with a
-// constraint sense (<=, >=, ==) to represent a generalized linear constraint
+// constraint sense (<=, >=, ==) to represent a generalized linear constraint.
+// Range constraints of the form lower <= x <= y are represented with an
+// additional constant lower bound.
 type Constr struct {
 	lhs   Expr
 	rhs   Expr
 	sense ConstrSense
+	lower float64
 }
 
 // LessEq returns a constraint representing lhs <= rhs
 func LessEq(lhs, rhs Expr) *Constr {
-	return &Constr{lhs, rhs, SenseLessThanEqual}
+	return &Constr{lhs: lhs, rhs: rhs, sense: SenseLessThanEqual}
 }
 
 // Eq returns a constraint representing lhs == rhs
 func Eq(lhs, rhs Expr) *Constr {
-	return &Constr{lhs, rhs, SenseEqual}
+	return &Constr{lhs: lhs, rhs: rhs, sense: SenseEqual}
 }
 
 // GreaterEq returns a constraint representing lhs >= rhs
 func GreaterEq(lhs, rhs Expr) *Constr {
-	return &Constr{lhs, rhs, SenseGreaterThanEqual}
+	return &Constr{lhs: lhs, rhs: rhs, sense: SenseGreaterThanEqual}
+}
+
+// Between returns a range constraint representing lower <= e <= upper. Unlike
+// adding separate LessEq and GreaterEq constraints, a range constraint is
+// passed to the solver as a single row.
+func Between(lower float64, e Expr, upper float64) *Constr {
+	return &Constr{lhs: e, rhs: K(upper), sense: SenseRange, lower: lower}
+}
+
+// rowBounds returns the bounds lower <= a x <= upper of the constraint once
+// all variables are moved to the left hand side and all constants to the
+// bounds. Bounds that do not apply are infinite.
+func (c *Constr) rowBounds() (float64, float64) {
+	constant := c.lhs.Constant() - c.rhs.Constant()
+	switch c.sense {
+	case SenseLessThanEqual:
+		return math.Inf(-1), -constant
+	case SenseGreaterThanEqual:
+		return -constant, math.Inf(1)
+	case SenseEqual:
+		return -constant, -constant
+	}
+
+	// The upper bound of a range is the constant on the right hand side
+	return c.lower - c.lhs.Constant(), c.rhs.Constant() - c.lhs.Constant()
 }
 
 // ConstrSense represents if the constraint x <= y, x >= y, or x == y. For easy
@@ -29,9 +59,11 @@ func GreaterEq(lhs, rhs Expr) *Constr {
 // the same way Gurobi encodes the constraint senses.
 type ConstrSense byte
 
-// Different constraint senses conforming to Gurobi's encoding.
+// Different constraint senses conforming to Gurobi's encoding. Gurobi has no
+// encoding for range constraints so SenseRange uses its own.
 const (
 	SenseEqual            ConstrSense = '='
 	SenseLessThanEqual                = '<'
 	SenseGreaterThanEqual             = '>'
+	SenseRange                        = 'R'
 )
@@ -30,8 +30,11 @@ func ExampleModel_simple() {
 	obj := goop.Sum(x, y, z.Mult(2))
 	m.SetObjective(obj, goop.SenseMaximize)
 
-	// Optimize the variables according to the model
-	sol, err := m.Optimize(solvers.NewLPSolveSolver())
+	// Optimize the variables according to the model. The solver is owned by
+	// the caller and is closed once it is no longer needed
+	solver := goop.NewSolver(solvers.NewLPSolveSolver())
+	defer solver.Close()
+	sol, err := m.Optimize(solver)
 
 	// Check if there is an error from the solver. No error should be returned
 	// for this model
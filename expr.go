@@ -46,14 +46,6 @@ func NewExpr(c float64) Expr {
 	return &LinearExpr{constant: c}
 }
 
-func getVarsPtr(e Expr) *uint64 {
-	if e.NumVars() > 0 {
-		return &e.Vars()[0]
-	}
-
-	return nil
-}
-
 func getCoeffsPtr(e Expr) *float64 {
 	if e.NumVars() > 0 {
 		return &e.Coeffs()[0]
@@ -0,0 +1,352 @@
+package goop
+
+import (
+	"errors"
+	"math"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// genConstrType identifies the kind of general (non-linear) constraint.
+type genConstrType int
+
+// Supported general constraint types
+const (
+	genMax genConstrType = iota
+	genMin
+)
+
+// genConstr represents a general constraint of the form
+// result = max(exprs...) or result = min(exprs...). General constraints are
+// replaced by an equivalent set of linear constraints when the model is
+// optimized.
+type genConstr struct {
+	gtype  genConstrType
+	result *Var
+	exprs  []Expr
+
+	// minLower is a lower bound of a max that holds regardless of the bounds
+	// of the expressions, such as zero for an absolute value
+	minLower float64
+}
+
+// uses returns true if the general constraint uses the variable with the
+// given ID as its result or in one of its expressions
+func (gc *genConstr) uses(id uint64) bool {
+	if gc.result.ID() == id {
+		return true
+	}
+
+	for _, e := range gc.exprs {
+		for _, other := range e.Vars() {
+			if other == id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AddMax adds a variable to the model that is constrained to be equal to the
+// maximum of the given expressions. The variable is returned. If the variable
+// is only ever pushed downwards by the objective and constraints (for example
+// when minimizing a max), the constraint is modelled as a pure LP using the
+// epigraph form. Otherwise, binary variables and big-M constraints built from
+// the bounds of the expressions are used, so the expressions must be bounded.
+// The bounds of the expressions are read whenever the model is optimized, so
+// they may be changed later. The returned variable is unbounded unless its
+// bounds are set using SetBounds.
+func (m *Model) AddMax(exprs ...Expr) *Var {
+	return m.addGenConstr(genMax, exprs, math.Inf(-1))
+}
+
+// AddMin adds a variable to the model that is constrained to be equal to the
+// minimum of the given expressions. The variable is returned. As with AddMax,
+// the epigraph form is used when the variable is only ever pushed upwards
+// (for example when maximizing a min) and a big-M formulation otherwise.
+func (m *Model) AddMin(exprs ...Expr) *Var {
+	return m.addGenConstr(genMin, exprs, math.Inf(-1))
+}
+
+// AddAbs adds a variable to the model that is constrained to be equal to the
+// absolute value of the given expression. The variable is returned. This is
+// equivalent to AddMax(e, -e).
+func (m *Model) AddAbs(e Expr) *Var {
+	return m.addGenConstr(genMax, []Expr{e, scaleExpr(e, -1)}, 0)
+}
+
+func (m *Model) addGenConstr(
+	gtype genConstrType, exprs []Expr, minLower float64,
+) *Var {
+	if len(exprs) == 0 {
+		log.Panic("General constraint requires at least one expression")
+	}
+
+	gc := &genConstr{
+		gtype:    gtype,
+		exprs:    make([]Expr, len(exprs)),
+		minLower: minLower,
+	}
+	for i, e := range exprs {
+		// Expressions are copied since LinearExprs are modified in place
+		gc.exprs[i] = scaleExpr(e, 1)
+	}
+
+	// The bounds implied by the expressions are not stored in the result
+	// since they change with the bounds of the expressions
+	gc.result = m.AddVar(math.Inf(-1), math.Inf(1), Continuous)
+	m.appendGenConstr(gc)
+	return gc.result
+}
+
+// appendGenConstr adds the general constraint to the model and records the
+// constraint its result belongs to
+func (m *Model) appendGenConstr(gc *genConstr) {
+	if m.results == nil {
+		m.results = make(map[uint64]*genConstr)
+	}
+
+	m.results[gc.result.ID()] = gc
+	m.genConstrs = append(m.genConstrs, gc)
+}
+
+// impliedBounds returns the bounds of the result of the general constraint
+// implied by the bounds of its expressions
+func (m *Model) impliedBounds(gc *genConstr) (float64, float64) {
+	lower, upper := math.Inf(-1), math.Inf(-1)
+	if gc.gtype == genMin {
+		lower, upper = math.Inf(1), math.Inf(1)
+	}
+
+	for _, e := range gc.exprs {
+		lo, hi := m.exprBounds(e)
+		if gc.gtype == genMax {
+			lower, upper = math.Max(lower, lo), math.Max(upper, hi)
+		} else {
+			lower, upper = math.Min(lower, lo), math.Min(upper, hi)
+		}
+	}
+
+	if gc.gtype == genMax {
+		lower = math.Max(lower, gc.minLower)
+	}
+
+	return lower, upper
+}
+
+// resultBounds returns the bounds of the result of the general constraint,
+// which are the bounds implied by its expressions tightened by the bounds
+// set on the result itself
+func (m *Model) resultBounds(gc *genConstr) (float64, float64) {
+	lower, upper := m.impliedBounds(gc)
+	return math.Max(lower, gc.result.Lower()),
+		math.Min(upper, gc.result.Upper())
+}
+
+// boundsOf returns the bounds of the variable, or the bounds of the result of
+// a general constraint if the variable is one
+func (m *Model) boundsOf(v *Var) (float64, float64) {
+	if gc, ok := m.results[v.ID()]; ok {
+		return m.resultBounds(gc)
+	}
+
+	return v.Lower(), v.Upper()
+}
+
+// exprBounds returns the smallest and largest values the given expression can
+// take based on the bounds of its variables.
+func (m *Model) exprBounds(e Expr) (float64, float64) {
+	lower, upper := e.Constant(), e.Constant()
+	coeffs := e.Coeffs()
+	for i, id := range e.Vars() {
+		v, ok := m.byID[id]
+		if !ok {
+			return math.Inf(-1), math.Inf(1)
+		}
+
+		lo, hi := m.boundsOf(v)
+		if coeffs[i] > 0 {
+			lower += coeffs[i] * lo
+			upper += coeffs[i] * hi
+		} else if coeffs[i] < 0 {
+			lower += coeffs[i] * hi
+			upper += coeffs[i] * lo
+		}
+	}
+
+	return lower, upper
+}
+
+// scaleExpr returns a new expression equal to c * e that does not share any
+// memory with e.
+func scaleExpr(e Expr, c float64) Expr {
+	newExpr := &LinearExpr{
+		vars:     append([]uint64{}, e.Vars()...),
+		coeffs:   make([]float64, e.NumVars()),
+		constant: c * e.Constant(),
+	}
+
+	for i, coeff := range e.Coeffs() {
+		newExpr.coeffs[i] = c * coeff
+	}
+
+	return newExpr
+}
+
+// epigraph returns the constraints result >= e for a max constraint or
+// result <= e for a min constraint for every expression e.
+func (gc *genConstr) epigraph() []*Constr {
+	constrs := make([]*Constr, len(gc.exprs))
+	for i, e := range gc.exprs {
+		if gc.gtype == genMax {
+			constrs[i] = gc.result.GreaterEq(e)
+		} else {
+			constrs[i] = gc.result.LessEq(e)
+		}
+	}
+
+	return constrs
+}
+
+// bigMs returns the big-M constant of every expression of the general
+// constraint, which is the largest amount by which the expression can fall
+// below (rise above) the result of a max (min) given the current bounds
+func (m *Model) bigMs(gc *genConstr) ([]float64, error) {
+	resLower, resUpper := m.resultBounds(gc)
+	bigMs := make([]float64, len(gc.exprs))
+
+	for i, e := range gc.exprs {
+		lo, hi := m.exprBounds(e)
+		if gc.gtype == genMax {
+			bigMs[i] = resUpper - lo
+		} else {
+			bigMs[i] = hi - resLower
+		}
+
+		if math.IsInf(bigMs[i], 0) || math.IsNaN(bigMs[i]) {
+			return nil, errors.New(
+				"cannot linearize general constraint with unbounded " +
+					"expressions; add finite bounds to its variables",
+			)
+		}
+	}
+
+	return bigMs, nil
+}
+
+// bigMConstrs returns the constraints that together with the epigraph
+// constraints and the constraint returned by selectOne force the result to be
+// equal to the expression whose binary variable is one
+func (gc *genConstr) bigMConstrs(bigMs []float64, binaries []*Var) []*Constr {
+	constrs := make([]*Constr, len(gc.exprs))
+	for i, e := range gc.exprs {
+		b := binaries[i]
+
+		// For max: result <= e + M (1 - b), for min: result >= e - M (1 - b)
+		if gc.gtype == genMax {
+			constrs[i] = gc.result.LessEq(
+				Sum(e, K(bigMs[i]), b.Mult(-bigMs[i])),
+			)
+		} else {
+			constrs[i] = gc.result.GreaterEq(
+				Sum(e, K(-bigMs[i]), b.Mult(bigMs[i])),
+			)
+		}
+	}
+
+	return constrs
+}
+
+// selectOne returns the constraint that exactly one of the binary variables
+// is one
+func selectOne(binaries []*Var) *Constr {
+	return SumVars(binaries...).Eq(One)
+}
+
+// isEpigraphExact returns true if the epigraph form of the general constraint
+// is guaranteed to yield a result equal to the max (or min) at optimality.
+// This is the case when the objective strictly prefers a smaller (larger)
+// result and the result can be decreased (increased) without violating any
+// other constraint in the model, including its own bounds.
+func (m *Model) isEpigraphExact(gc *genConstr) bool {
+	// Every stage of a hierarchical model has a different objective, and
+	// earlier objectives are turned into constraints
+	if m.obj == nil || len(m.objs) > 0 {
+		return false
+	}
+
+	// A bound that is tighter than the bounds of the expressions imply keeps
+	// the result from reaching the max (or min)
+	lower, upper := m.impliedBounds(gc)
+	if gc.gtype == genMax && exceeds(gc.result.Lower(), lower) ||
+		gc.gtype == genMin && exceeds(-gc.result.Upper(), -upper) {
+		return false
+	}
+
+	// dir is positive if the result must be pushed down by the model
+	dir := 1.0
+	if gc.gtype == genMin {
+		dir = -1
+	}
+
+	objCoeff := coeffOf(m.obj, gc.result.ID()) * float64(m.obj.sense)
+	if objCoeff*dir <= 0 {
+		return false
+	}
+
+	for _, constr := range m.constrs {
+		coeff := coeffOf(constr.lhs, gc.result.ID()) -
+			coeffOf(constr.rhs, gc.result.ID())
+		if coeff == 0 {
+			continue
+		}
+
+		switch constr.sense {
+		case SenseLessThanEqual:
+			if coeff*dir < 0 {
+				return false
+			}
+		case SenseGreaterThanEqual:
+			if coeff*dir > 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	for _, other := range m.genConstrs {
+		for _, e := range other.exprs {
+			if coeffOf(e, gc.result.ID()) != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// exceeds returns true if the value is larger than the limit by more than a
+// tolerance relative to the limit
+func exceeds(val, limit float64) bool {
+	if math.IsInf(limit, 0) {
+		return val > limit
+	}
+
+	return val > limit+1e-9*math.Max(1, math.Abs(limit))
+}
+
+// coeffOf returns the total coefficient of the variable with the given ID in
+// the expression.
+func coeffOf(e Expr, id uint64) float64 {
+	total := 0.0
+	coeffs := e.Coeffs()
+	for i, vid := range e.Vars() {
+		if vid == id {
+			total += coeffs[i]
+		}
+	}
+
+	return total
+}
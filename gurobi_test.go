@@ -5,15 +5,126 @@ package goop_test
 import (
 	"testing"
 
+	"github.com/mit-drl/goop"
 	"github.com/mit-drl/goop/solvers"
 )
 
 func TestGurobi(t *testing.T) {
 	t.Run("SimpleMIP", func(t *testing.T) {
-		solveSimpleMIPModel(t, solvers.NewGurobiSolver())
+		solveSimpleMIPModel(t, newGurobiSolver(t))
 	})
 
 	t.Run("SumRowsCols", func(t *testing.T) {
-		solveSumRowsColsModel(t, solvers.NewGurobiSolver())
+		solveSumRowsColsModel(t, newGurobiSolver(t))
 	})
+
+	t.Run("Max", func(t *testing.T) {
+		solveMaxModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Min", func(t *testing.T) {
+		solveMinModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Abs", func(t *testing.T) {
+		solveAbsModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("SemiContinuous", func(t *testing.T) {
+		solveSemiContinuousModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		solveRangeModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		solveUnboundedModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Session", func(t *testing.T) {
+		solveSessionModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("MutableVars", func(t *testing.T) {
+		solveMutableVarsModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		solveRemoveModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Lexicographic", func(t *testing.T) {
+		solveLexicographicModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Pareto", func(t *testing.T) {
+		solveParetoModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Pool", func(t *testing.T) {
+		solvePoolModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		solveProgressModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		solveContextModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		solveParamsModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Presolve", func(t *testing.T) {
+		solvePresolveModel(t, func() solvers.Solver {
+			return newGurobiSolver(t)
+		})
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		solveJSONModel(t, func() solvers.Solver {
+			return newGurobiSolver(t)
+		})
+	})
+
+	t.Run("CSR", func(t *testing.T) {
+		solveCSRModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		solveValuesModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		solveLifecycleModel(t, func() solvers.Solver {
+			return solvers.NewGurobiSolver()
+		})
+	})
+
+	t.Run("BoundedMax", func(t *testing.T) {
+		solveBoundedMaxModel(t, func() solvers.Solver {
+			return newGurobiSolver(t)
+		})
+	})
+
+	t.Run("WidenedMax", func(t *testing.T) {
+		solveWidenedMaxModel(t, newGurobiSolver(t))
+	})
+
+	t.Run("SolverPresolve", func(t *testing.T) {
+		solveSolverPresolveModel(t, newGurobiSolver(t))
+	})
+}
+
+// newGurobiSolver returns a Gurobi solver that is closed once the test finishes
+func newGurobiSolver(t *testing.T) *goop.Solver {
+	solver := goop.NewSolver(solvers.NewGurobiSolver())
+	t.Cleanup(func() {
+		solver.Close()
+	})
+
+	return solver
 }
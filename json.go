@@ -0,0 +1,641 @@
+package goop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// JSONVersion is the version of the JSON schema used to serialize models and
+// solutions. It is increased whenever the schema changes incompatibly.
+//
+// A model is serialized as
+//
+//	{
+//	  "version": 1,
+//	  "vars": [{"name": "x", "lower": 0, "upper": "Inf", "type": "C"}],
+//	  "constrs": [{"lhs": EXPR, "sense": "<=", "rhs": EXPR}],
+//	  "genConstrs": [{"type": "max", "result": 2, "exprs": [EXPR],
+//	    "minLower": 0}],
+//	  "objective": {"sense": "maximize", "expr": EXPR},
+//	  "objectives": [{"sense": "minimize", "expr": EXPR,
+//	    "priority": 1, "relTol": 0, "absTol": 0}],
+//	  "settings": {"showLog": false, "timeLimit": 10, "presolve": true,
+//	    "params": {"MIPGap": 0.01}}
+//	}
+//
+// where an EXPR is {"vars": [0, 1], "coeffs": [1, 2], "constant": 0} and
+// variables are referred to by their index in "vars". The sense of a
+// constraint is one of "<=", ">=", "=" or "range", in which case it has an
+// additional "lower" bound and the upper bound is the constant of "rhs".
+// A max may have a "minLower" bound that holds regardless of its
+// expressions, such as zero for an absolute value. Variable types use the
+// VarType encoding. The time limit is in seconds and zero means no limit.
+// Presolve is turned on unless "presolve" is false.
+// Infinite numbers are written as the strings "Inf" and "-Inf". Optional
+// fields may be omitted.
+//
+// A solution is serialized as
+//
+//	{
+//	  "version": 1,
+//	  "status": "optimal",
+//	  "optimal": true,
+//	  "objective": 12,
+//	  "objectives": [12, 3],
+//	  "gap": 0,
+//	  "values": [1, 2],
+//	  "names": ["x", ""]
+//	}
+//
+// where values are in the order of the variables of the solved model and
+// names holds their names, if any variable has one. The status is the string
+// returned by SolutionStatus.String. Names must be unique in both models and
+// solutions.
+const JSONVersion = 1
+
+// jsonFloat is a float64 that encodes infinite values as strings
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	switch {
+	case math.IsInf(float64(f), 1):
+		return []byte(`"Inf"`), nil
+	case math.IsInf(float64(f), -1):
+		return []byte(`"-Inf"`), nil
+	}
+
+	return json.Marshal(float64(f))
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"Inf"`:
+		*f = jsonFloat(math.Inf(1))
+		return nil
+	case `"-Inf"`:
+		*f = jsonFloat(math.Inf(-1))
+		return nil
+	}
+
+	return json.Unmarshal(data, (*float64)(f))
+}
+
+type jsonVar struct {
+	Name  string    `json:"name,omitempty"`
+	Lower jsonFloat `json:"lower"`
+	Upper jsonFloat `json:"upper"`
+	Type  string    `json:"type"`
+}
+
+type jsonExpr struct {
+	Vars     []int     `json:"vars"`
+	Coeffs   []float64 `json:"coeffs"`
+	Constant float64   `json:"constant"`
+}
+
+type jsonConstr struct {
+	LHS   jsonExpr   `json:"lhs"`
+	Sense string     `json:"sense"`
+	RHS   jsonExpr   `json:"rhs"`
+	Lower *jsonFloat `json:"lower,omitempty"`
+}
+
+type jsonGenConstr struct {
+	Type     string     `json:"type"`
+	Result   int        `json:"result"`
+	Exprs    []jsonExpr `json:"exprs"`
+	MinLower *jsonFloat `json:"minLower,omitempty"`
+}
+
+type jsonObjective struct {
+	Sense    string   `json:"sense"`
+	Expr     jsonExpr `json:"expr"`
+	Priority int      `json:"priority,omitempty"`
+	RelTol   float64  `json:"relTol,omitempty"`
+	AbsTol   float64  `json:"absTol,omitempty"`
+}
+
+type jsonSettings struct {
+	ShowLog   bool               `json:"showLog,omitempty"`
+	TimeLimit float64            `json:"timeLimit,omitempty"`
+	Presolve  *bool              `json:"presolve,omitempty"`
+	Params    map[string]float64 `json:"params,omitempty"`
+}
+
+type jsonModel struct {
+	Version    int             `json:"version"`
+	Vars       []jsonVar       `json:"vars"`
+	Constrs    []jsonConstr    `json:"constrs,omitempty"`
+	GenConstrs []jsonGenConstr `json:"genConstrs,omitempty"`
+	Objective  *jsonObjective  `json:"objective,omitempty"`
+	Objectives []jsonObjective `json:"objectives,omitempty"`
+	Settings   jsonSettings    `json:"settings"`
+}
+
+var constrSenseNames = map[ConstrSense]string{
+	SenseLessThanEqual:    "<=",
+	SenseGreaterThanEqual: ">=",
+	SenseEqual:            "=",
+	SenseRange:            "range",
+}
+
+// MarshalJSON encodes the model using the schema described by JSONVersion
+func (m *Model) MarshalJSON() ([]byte, error) {
+	index := make(map[uint64]int, len(m.vars))
+	jm := jsonModel{
+		Version: JSONVersion,
+		Vars:    make([]jsonVar, len(m.vars)),
+		Settings: jsonSettings{
+			ShowLog:   m.showLog,
+			TimeLimit: m.timeLimit.Seconds(),
+		},
+	}
+
+	if m.noPresolve {
+		presolve := false
+		jm.Settings.Presolve = &presolve
+	}
+
+	names := make([]string, len(m.vars))
+	for i, v := range m.vars {
+		index[v.ID()] = i
+		names[i] = v.name
+		jm.Vars[i] = jsonVar{
+			v.name, jsonFloat(v.lower), jsonFloat(v.upper), string(v.vtype),
+		}
+	}
+
+	if err := checkNames(names); err != nil {
+		return nil, err
+	}
+
+	toJSON := func(e Expr) (jsonExpr, error) {
+		je := jsonExpr{
+			Vars:     make([]int, e.NumVars()),
+			Coeffs:   append([]float64{}, e.Coeffs()...),
+			Constant: e.Constant(),
+		}
+
+		for i, id := range e.Vars() {
+			idx, ok := index[id]
+			if !ok {
+				return je, fmt.Errorf(
+					"variable %d is not part of the model", id,
+				)
+			}
+
+			je.Vars[i] = idx
+		}
+
+		return je, nil
+	}
+
+	for _, c := range m.constrs {
+		lhs, err := toJSON(c.lhs)
+		if err != nil {
+			return nil, err
+		}
+
+		rhs, err := toJSON(c.rhs)
+		if err != nil {
+			return nil, err
+		}
+
+		jc := jsonConstr{LHS: lhs, Sense: constrSenseNames[c.sense], RHS: rhs}
+		if c.sense == SenseRange {
+			lower := jsonFloat(c.lower)
+			jc.Lower = &lower
+		}
+
+		jm.Constrs = append(jm.Constrs, jc)
+	}
+
+	for _, gc := range m.genConstrs {
+		jgc := jsonGenConstr{Type: "max", Result: index[gc.result.ID()]}
+		if gc.gtype == genMin {
+			jgc.Type = "min"
+		}
+
+		if !math.IsInf(gc.minLower, -1) {
+			minLower := jsonFloat(gc.minLower)
+			jgc.MinLower = &minLower
+		}
+
+		for _, e := range gc.exprs {
+			je, err := toJSON(e)
+			if err != nil {
+				return nil, err
+			}
+
+			jgc.Exprs = append(jgc.Exprs, je)
+		}
+
+		jm.GenConstrs = append(jm.GenConstrs, jgc)
+	}
+
+	objToJSON := func(o *Objective) (jsonObjective, error) {
+		je, err := toJSON(o)
+		sense := "minimize"
+		if o.sense == SenseMaximize {
+			sense = "maximize"
+		}
+
+		return jsonObjective{Sense: sense, Expr: je}, err
+	}
+
+	if m.obj != nil {
+		jo, err := objToJSON(m.obj)
+		if err != nil {
+			return nil, err
+		}
+
+		jm.Objective = &jo
+	}
+
+	for _, o := range m.objs {
+		jo, err := objToJSON(o.Objective)
+		if err != nil {
+			return nil, err
+		}
+
+		jo.Priority, jo.RelTol, jo.AbsTol = o.priority, o.relTol, o.absTol
+		jm.Objectives = append(jm.Objectives, jo)
+	}
+
+	if len(m.params) > 0 {
+		jm.Settings.Params = make(map[string]float64, len(m.params))
+		for param, value := range m.params {
+			jm.Settings.Params[param.String()] = value
+		}
+	}
+
+	return marshalJSON(jm)
+}
+
+// UnmarshalJSON replaces the model with the model encoded using the schema
+// described by JSONVersion. The variables of the model are new variables with
+// new IDs, so variables of any previous content of the model are no longer
+// part of it.
+func (m *Model) UnmarshalJSON(data []byte) error {
+	var jm jsonModel
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+
+	if jm.Version != JSONVersion {
+		return fmt.Errorf("unsupported model version %d", jm.Version)
+	}
+
+	newModel := NewModel()
+	names := make([]string, len(jm.Vars))
+	for i, jv := range jm.Vars {
+		if len(jv.Type) != 1 {
+			return fmt.Errorf("invalid variable type %q", jv.Type)
+		}
+
+		v := newModel.AddVar(
+			float64(jv.Lower), float64(jv.Upper), VarType(jv.Type[0]),
+		)
+		v.name = jv.Name
+		names[i] = jv.Name
+	}
+
+	if err := checkNames(names); err != nil {
+		return err
+	}
+
+	fromJSON := func(je jsonExpr) (Expr, error) {
+		if len(je.Vars) != len(je.Coeffs) {
+			return nil, errors.New("expression vars and coeffs differ in length")
+		}
+
+		e := &LinearExpr{
+			vars:     make([]uint64, len(je.Vars)),
+			coeffs:   append([]float64{}, je.Coeffs...),
+			constant: je.Constant,
+		}
+
+		for i, idx := range je.Vars {
+			if idx < 0 || idx >= len(newModel.vars) {
+				return nil, fmt.Errorf("invalid variable index %d", idx)
+			}
+
+			e.vars[i] = newModel.vars[idx].ID()
+		}
+
+		return e, nil
+	}
+
+	for _, jc := range jm.Constrs {
+		lhs, err := fromJSON(jc.LHS)
+		if err != nil {
+			return err
+		}
+
+		rhs, err := fromJSON(jc.RHS)
+		if err != nil {
+			return err
+		}
+
+		c := &Constr{lhs: lhs, rhs: rhs}
+		for sense, name := range constrSenseNames {
+			if name == jc.Sense {
+				c.sense = sense
+			}
+		}
+
+		switch {
+		case c.sense == 0:
+			return fmt.Errorf("invalid constraint sense %q", jc.Sense)
+		case c.sense == SenseRange && jc.Lower == nil:
+			return errors.New("range constraint without lower bound")
+		case c.sense == SenseRange:
+			c.lower = float64(*jc.Lower)
+		}
+
+		newModel.constrs = append(newModel.constrs, c)
+	}
+
+	for _, jgc := range jm.GenConstrs {
+		if jgc.Result < 0 || jgc.Result >= len(newModel.vars) {
+			return fmt.Errorf("invalid variable index %d", jgc.Result)
+		}
+
+		gc := &genConstr{
+			result:   newModel.vars[jgc.Result],
+			minLower: math.Inf(-1),
+		}
+		if jgc.MinLower != nil {
+			gc.minLower = float64(*jgc.MinLower)
+		}
+
+		switch jgc.Type {
+		case "max":
+			gc.gtype = genMax
+		case "min":
+			gc.gtype = genMin
+		default:
+			return fmt.Errorf("invalid general constraint type %q", jgc.Type)
+		}
+
+		for _, je := range jgc.Exprs {
+			e, err := fromJSON(je)
+			if err != nil {
+				return err
+			}
+
+			gc.exprs = append(gc.exprs, e)
+		}
+
+		newModel.appendGenConstr(gc)
+	}
+
+	objFromJSON := func(jo jsonObjective) (*Objective, error) {
+		e, err := fromJSON(jo.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch jo.Sense {
+		case "minimize":
+			return NewObjective(e, SenseMinimize), nil
+		case "maximize":
+			return NewObjective(e, SenseMaximize), nil
+		}
+
+		return nil, fmt.Errorf("invalid objective sense %q", jo.Sense)
+	}
+
+	if jm.Objective != nil {
+		obj, err := objFromJSON(*jm.Objective)
+		if err != nil {
+			return err
+		}
+
+		newModel.obj = obj
+	}
+
+	for _, jo := range jm.Objectives {
+		obj, err := objFromJSON(jo)
+		if err != nil {
+			return err
+		}
+
+		newModel.objs = append(newModel.objs, &prioritizedObjective{
+			obj, jo.Priority, jo.RelTol, jo.AbsTol,
+		})
+	}
+
+	newModel.showLog = jm.Settings.ShowLog
+	newModel.timeLimit = time.Duration(
+		jm.Settings.TimeLimit * float64(time.Second),
+	)
+	newModel.noPresolve = jm.Settings.Presolve != nil && !*jm.Settings.Presolve
+
+	for name, value := range jm.Settings.Params {
+		param, ok := paramByName(name)
+		if !ok {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+
+		newModel.SetParam(param, value)
+	}
+
+	*m = *newModel
+	return nil
+}
+
+// marshalJSON encodes v like json.Marshal without escaping the < and >
+// characters of constraint senses
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// paramByName returns the parameter with the given name
+func paramByName(name string) (Param, bool) {
+	for i, paramName := range paramNames {
+		if paramName == name {
+			return Param(i), true
+		}
+	}
+
+	return 0, false
+}
+
+type jsonSolution struct {
+	Version    int         `json:"version"`
+	Status     string      `json:"status"`
+	Optimal    bool        `json:"optimal"`
+	Objective  jsonFloat   `json:"objective"`
+	Objectives []jsonFloat `json:"objectives,omitempty"`
+	Gap        float64     `json:"gap"`
+	Values     []jsonFloat `json:"values"`
+	Names      []string    `json:"names,omitempty"`
+}
+
+// checkNames returns an error if two variables have the same name, since
+// they could not be told apart when decoding a solution by name
+func checkNames(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		if seen[name] {
+			return fmt.Errorf("duplicate variable name %q", name)
+		}
+
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the solution using the schema described by
+// JSONVersion.
+func (s *Solution) MarshalJSON() ([]byte, error) {
+	if err := checkNames(s.names); err != nil {
+		return nil, err
+	}
+
+	js := jsonSolution{
+		Version:   JSONVersion,
+		Status:    s.Status.String(),
+		Optimal:   s.Optimal,
+		Objective: jsonFloat(s.Objective),
+		Gap:       s.Gap,
+		Values:    make([]jsonFloat, len(s.names)),
+	}
+
+	for _, val := range s.Objectives {
+		js.Objectives = append(js.Objectives, jsonFloat(val))
+	}
+
+	for _, name := range s.names {
+		if name != "" {
+			js.Names = s.names
+			break
+		}
+	}
+
+	for i := range s.names {
+		// Solutions decoded without a model only have their values in order
+		if s.vars == nil {
+			js.Values[i] = jsonFloat(s.vals[i])
+			continue
+		}
+
+		val, ok := s.value(s.vars[i])
+		if !ok {
+			return nil, fmt.Errorf(
+				"variable %d is not part of the solution", s.vars[i],
+			)
+		}
+
+		js.Values[i] = jsonFloat(val)
+	}
+
+	return marshalJSON(js)
+}
+
+// UnmarshalJSON replaces the solution with the solution encoded using the
+// schema described by JSONVersion. Since the solution is not bound to a
+// model, Value only returns the values of variables looked up by their name.
+// Use Model.UnmarshalSolution to look up the values of unnamed variables.
+func (s *Solution) UnmarshalJSON(data []byte) error {
+	var js jsonSolution
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+
+	if js.Version != JSONVersion {
+		return fmt.Errorf("unsupported solution version %d", js.Version)
+	}
+
+	if js.Names == nil {
+		js.Names = make([]string, len(js.Values))
+	}
+
+	if len(js.Names) != len(js.Values) {
+		return errors.New("solution names and values differ in length")
+	}
+
+	if err := checkNames(js.Names); err != nil {
+		return err
+	}
+
+	newSol := Solution{
+		vals:      make([]float64, len(js.Values)),
+		names:     js.Names,
+		keyed:     make(map[string]float64),
+		Optimal:   js.Optimal,
+		Objective: float64(js.Objective),
+		Gap:       js.Gap,
+	}
+
+	status, ok := statusByName(js.Status)
+	if !ok {
+		return fmt.Errorf("invalid solution status %q", js.Status)
+	}
+
+	newSol.Status = status
+	for _, val := range js.Objectives {
+		newSol.Objectives = append(newSol.Objectives, float64(val))
+	}
+
+	for i, val := range js.Values {
+		newSol.vals[i] = float64(val)
+		if name := js.Names[i]; name != "" {
+			newSol.keyed[name] = float64(val)
+		}
+	}
+
+	*s = newSol
+	return nil
+}
+
+// UnmarshalSolution decodes a solution of the model encoded using the schema
+// described by JSONVersion. The values are bound to the variables of the
+// model by their index, so the model must have the same variables in the
+// same order, with the same names, as the model that was solved.
+func (m *Model) UnmarshalSolution(data []byte) (*Solution, error) {
+	sol := new(Solution)
+	if err := json.Unmarshal(data, sol); err != nil {
+		return nil, err
+	}
+
+	if len(sol.vals) != len(m.vars) {
+		return nil, fmt.Errorf(
+			"solution has %d values but the model has %d variables",
+			len(sol.vals), len(m.vars),
+		)
+	}
+
+	sol.cols = make(map[uint64]int, len(m.vars))
+	for i, v := range m.vars {
+		if sol.names[i] != "" && sol.names[i] != v.name {
+			return nil, fmt.Errorf(
+				"variable %d is named %q in the model but %q in the solution",
+				i, v.name, sol.names[i],
+			)
+		}
+
+		sol.cols[v.ID()] = i
+	}
+
+	sol.keyed = nil
+	sol.setVars(m.vars)
+	return sol, nil
+}
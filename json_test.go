@@ -0,0 +1,156 @@
+package goop_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestModelJSON(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, goop.Inf, goop.Continuous)
+	y := m.AddVar(-goop.Inf, 10, goop.Integer)
+	z := m.AddBinaryVar()
+	m.SetName(x, "x")
+	m.SetName(y, "y")
+	m.AddConstr(goop.Sum(x, y.Mult(2)).LessEq(goop.Sum(z, goop.K(4))))
+	m.AddConstr(goop.Between(-1, goop.Sum(x, z), 3))
+	m.AddMax(x, y)
+	m.SetObjective(goop.Sum(x, y, z.Mult(2)), goop.SenseMaximize)
+	m.SetTimeLimit(5 * time.Second)
+	m.SetParam(goop.ParamMIPGap, 0.01)
+	m.SetPresolve(false)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := goop.NewModel()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unnamed variables get new IDs, so the models are compared by their
+	// encoding instead of their algebraic form
+	redata, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(redata) != string(data) {
+		t.Errorf("JSON mismatch: %s != %s", redata, data)
+	}
+
+	// Presolve is turned on when the setting is omitted
+	if err := json.Unmarshal([]byte(`{"version": 1, "vars": [],
+		"settings": {}}`), decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":1,"vars":[],"settings":{}}`
+	if string(data) != expected {
+		t.Errorf("JSON mismatch: %s != %s", data, expected)
+	}
+
+	if err := json.Unmarshal([]byte(`{"version": 2}`), decoded); err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+}
+
+func TestSolutionJSON(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+	y := m.AddVar(0, 1, goop.Continuous)
+	m.SetName(x, "x")
+
+	data := []byte(`{"version": 1, "status": "suboptimal", "optimal": false,
+		"objective": "-Inf", "gap": 0.5, "values": [0.25, 0.75],
+		"names": ["x", ""]}`)
+
+	var sol goop.Solution
+	if err := json.Unmarshal(data, &sol); err != nil {
+		t.Fatal(err)
+	}
+
+	if sol.Status != goop.StatusSuboptimal || !math.IsInf(sol.Objective, -1) ||
+		sol.Gap != 0.5 {
+		t.Errorf("Solution mismatch: %+v", sol)
+	}
+
+	if sol.Value(x) != 0.25 {
+		t.Errorf("Value mismatch: %v != 0.25", sol.Value(x))
+	}
+
+	redata, err := json.Marshal(&sol)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":1,"status":"suboptimal","optimal":false,` +
+		`"objective":"-Inf","gap":0.5,"values":[0.25,0.75],` +
+		`"names":["x",""]}`
+	if string(redata) != expected {
+		t.Errorf("JSON mismatch: %s != %s", redata, expected)
+	}
+
+	// Unnamed variables are looked up by their index in the model
+	bound, err := m.UnmarshalSolution(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bound.Value(y) != 0.75 {
+		t.Errorf("Value mismatch: %v != 0.75", bound.Value(y))
+	}
+
+	rebound, err := json.Marshal(bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(rebound) != expected {
+		t.Errorf("JSON mismatch: %s != %s", rebound, expected)
+	}
+
+	m.SetName(y, "y")
+	mismatched := []byte(`{"version": 1, "status": "optimal",
+		"objective": 0, "gap": 0, "values": [0, 1], "names": ["x", "z"]}`)
+	if _, err := m.UnmarshalSolution(mismatched); err == nil {
+		t.Error("Expected error for mismatched variable names")
+	}
+
+	if _, err := m.UnmarshalSolution([]byte(`{"version": 1,
+		"status": "optimal", "objective": 0, "gap": 0,
+		"values": [0]}`)); err == nil {
+		t.Error("Expected error for missing values")
+	}
+}
+
+func TestDuplicateNamesJSON(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+	y := m.AddVar(0, 1, goop.Continuous)
+	m.SetName(x, "x")
+	m.SetName(y, "x")
+
+	if _, err := json.Marshal(m); err == nil {
+		t.Error("Expected error for duplicate variable names")
+	}
+
+	data := []byte(`{"version": 1, "status": "optimal", "objective": 0,
+		"gap": 0, "values": [0, 1], "names": ["x", "x"]}`)
+
+	var sol goop.Solution
+	if err := json.Unmarshal(data, &sol); err == nil {
+		t.Error("Expected error for duplicate variable names")
+	}
+}
@@ -0,0 +1,46 @@
+package goop
+
+import (
+	"errors"
+	"math"
+)
+
+// varFactory creates an auxiliary variable used to linearize part of a model.
+// Auxiliary variables are not part of the model itself.
+type varFactory func(lower, upper float64, vtype VarType) *Var
+
+// relaxSemi returns a copy of a semi-continuous or semi-integer variable whose
+// type is continuous or integer and whose bounds are relaxed to contain zero.
+// The constraints returned by semiIndicatorConstrs must be added to restore
+// the semantics of the original variable.
+func relaxSemi(v *Var) *Var {
+	vtype := Continuous
+	if v.Type() == SemiInteger {
+		vtype = Integer
+	}
+
+	return &Var{
+		id:    v.ID(),
+		lower: math.Min(v.Lower(), 0),
+		upper: math.Max(v.Upper(), 0),
+		vtype: vtype,
+	}
+}
+
+// semiIndicatorConstrs returns the constraints lower * z <= v <= upper * z
+// where z is a new binary variable created using newVar, which is also
+// returned. Together with the relaxed bounds from relaxSemi, these
+// constraints force v to be zero or between its bounds.
+func semiIndicatorConstrs(v *Var, newVar varFactory) (*Var, []*Constr, error) {
+	if math.IsInf(v.Lower(), 0) || math.IsInf(v.Upper(), 0) {
+		return nil, nil, errors.New(
+			"cannot reformulate semi-continuous variable with infinite bounds",
+		)
+	}
+
+	z := newVar(0, 1, Binary)
+	return z, []*Constr{
+		v.LessEq(z.Mult(v.Upper())),
+		v.GreaterEq(z.Mult(v.Lower())),
+	}, nil
+}
@@ -3,15 +3,126 @@ package goop_test
 import (
 	"testing"
 
+	"github.com/mit-drl/goop"
 	"github.com/mit-drl/goop/solvers"
 )
 
 func TestLPSolve(t *testing.T) {
 	t.Run("SimpleMIP", func(t *testing.T) {
-		solveSimpleMIPModel(t, solvers.NewLPSolveSolver())
+		solveSimpleMIPModel(t, newLPSolveSolver(t))
 	})
 
 	t.Run("SumRowsCols", func(t *testing.T) {
-		solveSumRowsColsModel(t, solvers.NewLPSolveSolver())
+		solveSumRowsColsModel(t, newLPSolveSolver(t))
 	})
+
+	t.Run("Max", func(t *testing.T) {
+		solveMaxModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Min", func(t *testing.T) {
+		solveMinModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Abs", func(t *testing.T) {
+		solveAbsModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("SemiContinuous", func(t *testing.T) {
+		solveSemiContinuousModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		solveRangeModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		solveUnboundedModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Session", func(t *testing.T) {
+		solveSessionModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("MutableVars", func(t *testing.T) {
+		solveMutableVarsModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		solveRemoveModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Lexicographic", func(t *testing.T) {
+		solveLexicographicModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Pareto", func(t *testing.T) {
+		solveParetoModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Pool", func(t *testing.T) {
+		solvePoolModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		solveProgressModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		solveContextModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		solveParamsModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Presolve", func(t *testing.T) {
+		solvePresolveModel(t, func() solvers.Solver {
+			return newLPSolveSolver(t)
+		})
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		solveJSONModel(t, func() solvers.Solver {
+			return newLPSolveSolver(t)
+		})
+	})
+
+	t.Run("CSR", func(t *testing.T) {
+		solveCSRModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		solveValuesModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		solveLifecycleModel(t, func() solvers.Solver {
+			return solvers.NewLPSolveSolver()
+		})
+	})
+
+	t.Run("BoundedMax", func(t *testing.T) {
+		solveBoundedMaxModel(t, func() solvers.Solver {
+			return newLPSolveSolver(t)
+		})
+	})
+
+	t.Run("WidenedMax", func(t *testing.T) {
+		solveWidenedMaxModel(t, newLPSolveSolver(t))
+	})
+
+	t.Run("SolverPresolve", func(t *testing.T) {
+		solveSolverPresolveModel(t, newLPSolveSolver(t))
+	})
+}
+
+// newLPSolveSolver returns an lp_solve solver that is closed once the test finishes
+func newLPSolveSolver(t *testing.T) *goop.Solver {
+	solver := goop.NewSolver(solvers.NewLPSolveSolver())
+	t.Cleanup(func() {
+		solver.Close()
+	})
+
+	return solver
 }
@@ -0,0 +1,254 @@
+package goop
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AddConstrsCSR adds the constraints A x (senses) rhs to the model, where row
+// i of the sparse matrix A holds the coefficients vals[rowPtr[i]:rowPtr[i+1]]
+// of the variables vars[colIdx[k]] for every k in that range. The constraints
+// are returned in row order so they can later be removed. They share a single
+// copy of the matrix instead of allocating an expression per term, and are
+// sent to the solver in one batch together with all other new constraints.
+// Range constraints are not supported since they need two bounds per row.
+func (m *Model) AddConstrsCSR(
+	vars []*Var, rowPtr []int, colIdx []int, vals []float64,
+	senses []ConstrSense, rhs []float64,
+) ([]*Constr, error) {
+	numRows := len(senses)
+	if len(rhs) != numRows || len(rowPtr) != numRows+1 {
+		return nil, fmt.Errorf(
+			"%d senses, %d right hand sides and %d row pointers do not "+
+				"describe the same number of rows", len(senses), len(rhs),
+			len(rowPtr),
+		)
+	}
+
+	if len(colIdx) != len(vals) || rowPtr[0] != 0 ||
+		rowPtr[numRows] != len(vals) {
+		return nil, errors.New(
+			"row pointers, column indices and values do not match",
+		)
+	}
+
+	for i := 0; i < numRows; i++ {
+		if rowPtr[i] > rowPtr[i+1] {
+			return nil, errors.New("row pointers are not increasing")
+		}
+	}
+
+	ids := make([]uint64, len(colIdx))
+	for k, col := range colIdx {
+		if col < 0 || col >= len(vars) {
+			return nil, fmt.Errorf("column index %d out of range", col)
+		}
+
+		ids[k] = vars[col].ID()
+	}
+
+	// Values are copied since LinearExprs are modified in place
+	coeffs := append([]float64{}, vals...)
+	constrs := make([]*Constr, numRows)
+	for i, sense := range senses {
+		start, end := rowPtr[i], rowPtr[i+1]
+		switch sense {
+		case SenseLessThanEqual, SenseGreaterThanEqual, SenseEqual:
+		default:
+			return nil, fmt.Errorf("invalid constraint sense %q", sense)
+		}
+
+		// The capacity of every row ends with the row so that appending to
+		// its expression does not overwrite the next row
+		lhs := &LinearExpr{
+			vars:   ids[start:end:end],
+			coeffs: coeffs[start:end:end],
+		}
+
+		constrs[i] = &Constr{lhs: lhs, rhs: K(rhs[i]), sense: sense}
+	}
+
+	m.constrs = append(m.constrs, constrs...)
+	return constrs, nil
+}
+
+// AddConstrsDense adds the constraints A x (senses) rhs to the model for a
+// dense matrix A with one row per constraint and one column per variable.
+// Zero coefficients are dropped and the constraints are added using
+// AddConstrsCSR.
+func (m *Model) AddConstrsDense(
+	vars []*Var, a [][]float64, senses []ConstrSense, rhs []float64,
+) ([]*Constr, error) {
+	rowPtr := make([]int, 1, len(a)+1)
+	var colIdx []int
+	var vals []float64
+	for i, row := range a {
+		if len(row) != len(vars) {
+			return nil, fmt.Errorf(
+				"row %d has %d coefficients for %d variables",
+				i, len(row), len(vars),
+			)
+		}
+
+		for j, val := range row {
+			if val != 0 {
+				colIdx = append(colIdx, j)
+				vals = append(vals, val)
+			}
+		}
+
+		rowPtr = append(rowPtr, len(vals))
+	}
+
+	return m.AddConstrsCSR(vars, rowPtr, colIdx, vals, senses, rhs)
+}
+
+// MatrixForm is a model in the standard matrix form
+//
+//	optimize  c x + offset
+//	s.t.      rowLower <= A x <= rowUpper
+//	          colLower <= x <= colUpper
+//
+// Column j corresponds to Vars[j] and row i to Constrs[i]. Bounds that do
+// not apply are infinite.
+type MatrixForm struct {
+	// A in compressed sparse row form, where row i holds the values
+	// Vals[RowPtr[i]:RowPtr[i+1]] in the columns ColIdx[RowPtr[i]:RowPtr[i+1]]
+	RowPtr []int
+	ColIdx []int
+	Vals   []float64
+
+	// A in compressed sparse column form, where column j holds the values
+	// ColVals[ColPtr[j]:ColPtr[j+1]] in the rows RowIdx[ColPtr[j]:ColPtr[j+1]]
+	ColPtr  []int
+	RowIdx  []int
+	ColVals []float64
+
+	RowLower, RowUpper []float64
+	ColLower, ColUpper []float64
+
+	Obj       []float64
+	ObjOffset float64
+	ObjSense  ObjSense
+
+	// Integer is true for the columns of Binary, Integer and SemiInteger
+	// variables, and Types holds the type of every column
+	Integer []bool
+	Types   []VarType
+
+	Vars    []*Var
+	Constrs []*Constr
+}
+
+// MatrixForm returns the model in standard matrix form. Duplicate variables
+// within a constraint or the objective are merged and zero coefficients are
+// dropped. Models with general constraints or hierarchical objectives have no
+// matrix form since they are only linearized by the solver session.
+func (m *Model) MatrixForm() (*MatrixForm, error) {
+	if len(m.genConstrs) > 0 {
+		return nil, errors.New("general constraints have no matrix form")
+	}
+
+	if len(m.objs) > 0 {
+		return nil, errors.New("hierarchical objectives have no matrix form")
+	}
+
+	numVars := len(m.vars)
+	mf := &MatrixForm{
+		RowPtr:   make([]int, 1, len(m.constrs)+1),
+		RowLower: make([]float64, len(m.constrs)),
+		RowUpper: make([]float64, len(m.constrs)),
+		ColLower: make([]float64, numVars),
+		ColUpper: make([]float64, numVars),
+		Obj:      make([]float64, numVars),
+		ObjSense: SenseMinimize,
+		Integer:  make([]bool, numVars),
+		Types:    make([]VarType, numVars),
+		Vars:     append([]*Var{}, m.vars...),
+		Constrs:  append([]*Constr{}, m.constrs...),
+	}
+
+	cols := make(map[uint64]int, numVars)
+	for j, v := range m.vars {
+		cols[v.ID()] = j
+		mf.ColLower[j], mf.ColUpper[j] = v.Lower(), v.Upper()
+		mf.Types[j] = v.Type()
+		mf.Integer[j] = v.Type() == Binary || v.Type() == Integer ||
+			v.Type() == SemiInteger
+	}
+
+	column := func(id uint64) (int, error) {
+		j, ok := cols[id]
+		if !ok {
+			return 0, fmt.Errorf("variable %d is not part of the model", id)
+		}
+
+		return j, nil
+	}
+
+	for i, constr := range m.constrs {
+		row := newPSRow(constr, i)
+		mf.RowLower[i], mf.RowUpper[i] = row.lo, row.hi
+		for k, id := range row.vars {
+			j, err := column(id)
+			if err != nil {
+				return nil, err
+			}
+
+			if row.coeffs[k] != 0 {
+				mf.ColIdx = append(mf.ColIdx, j)
+				mf.Vals = append(mf.Vals, row.coeffs[k])
+			}
+		}
+
+		mf.RowPtr = append(mf.RowPtr, len(mf.Vals))
+	}
+
+	if m.obj != nil {
+		mf.ObjSense = m.obj.sense
+		mf.ObjOffset = m.obj.Constant()
+		coeffs := m.obj.Coeffs()
+		for k, id := range m.obj.Vars() {
+			j, err := column(id)
+			if err != nil {
+				return nil, err
+			}
+
+			mf.Obj[j] += coeffs[k]
+		}
+	}
+
+	mf.ColPtr, mf.RowIdx, mf.ColVals = transposeCSR(
+		numVars, mf.RowPtr, mf.ColIdx, mf.Vals,
+	)
+	return mf, nil
+}
+
+// transposeCSR returns the compressed sparse column form of a matrix with
+// the given number of columns in compressed sparse row form
+func transposeCSR(
+	numCols int, rowPtr, colIdx []int, vals []float64,
+) ([]int, []int, []float64) {
+	colPtr := make([]int, numCols+1)
+	for _, j := range colIdx {
+		colPtr[j+1]++
+	}
+
+	for j := 0; j < numCols; j++ {
+		colPtr[j+1] += colPtr[j]
+	}
+
+	next := append([]int{}, colPtr[:numCols]...)
+	rowIdx := make([]int, len(colIdx))
+	colVals := make([]float64, len(vals))
+	for i := 0; i+1 < len(rowPtr); i++ {
+		for k := rowPtr[i]; k < rowPtr[i+1]; k++ {
+			j := colIdx[k]
+			rowIdx[next[j]] = i
+			colVals[next[j]] = vals[k]
+			next[j]++
+		}
+	}
+
+	return colPtr, rowIdx, colVals
+}
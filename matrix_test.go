@@ -0,0 +1,171 @@
+package goop_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestAddConstrsCSR(t *testing.T) {
+	m := goop.NewModel()
+	xs := m.AddVarVector(3, 0, 10, goop.Continuous)
+	for i, name := range []string{"x", "y", "z"} {
+		m.SetName(xs[i], name)
+	}
+
+	constrs, err := m.AddConstrsCSR(
+		xs, []int{0, 2, 3}, []int{0, 2, 1}, []float64{1, 3, -2},
+		[]goop.ConstrSense{goop.SenseLessThanEqual, goop.SenseEqual},
+		[]float64{4, 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(constrs) != 2 {
+		t.Fatalf("Length mismatch: %v != 2", len(constrs))
+	}
+
+	m.SetObjective(goop.SumVars(xs...), goop.SenseMinimize)
+	expected := "minimize x + y + z  s.t.  c0: x + 3 z <= 4  c1: -2 y = 1"
+	if s := m.String(); s != expected {
+		t.Errorf("String mismatch: %q != %q", s, expected)
+	}
+
+	_, err = m.AddConstrsCSR(
+		xs, []int{0, 1}, []int{3}, []float64{1},
+		[]goop.ConstrSense{goop.SenseLessThanEqual}, []float64{1},
+	)
+	if err == nil {
+		t.Error("Expected error for column index out of range")
+	}
+
+	_, err = m.AddConstrsCSR(
+		xs, []int{0, 1}, []int{0}, []float64{1},
+		[]goop.ConstrSense{goop.SenseRange}, []float64{1},
+	)
+	if err == nil {
+		t.Error("Expected error for range sense")
+	}
+
+	_, err = m.AddConstrsCSR(
+		xs, []int{0, 5, 3}, []int{0, 1, 2}, []float64{1, 1, 1},
+		[]goop.ConstrSense{goop.SenseLessThanEqual, goop.SenseEqual},
+		[]float64{1, 1},
+	)
+	if err == nil {
+		t.Error("Expected error for decreasing row pointers")
+	}
+}
+
+func TestAddConstrsDense(t *testing.T) {
+	m := goop.NewModel()
+	xs := m.AddVarVector(2, 0, 10, goop.Continuous)
+	constrs, err := m.AddConstrsDense(
+		xs, [][]float64{{1, 0}, {2, 3}},
+		[]goop.ConstrSense{goop.SenseGreaterThanEqual, goop.SenseLessThanEqual},
+		[]float64{1, 6},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(constrs) != 2 {
+		t.Fatalf("Length mismatch: %v != 2", len(constrs))
+	}
+
+	if err := m.RemoveConstr(constrs[0]); err != nil {
+		t.Error(err)
+	}
+
+	_, err = m.AddConstrsDense(
+		xs, [][]float64{{1}}, []goop.ConstrSense{goop.SenseEqual},
+		[]float64{1},
+	)
+	if err == nil {
+		t.Error("Expected error for short row")
+	}
+}
+
+func TestMatrixForm(t *testing.T) {
+	m := goop.NewModel()
+	xs := m.AddVarVector(3, 0, goop.Inf, goop.Continuous)
+	m.SetType(xs[2], goop.Integer)
+	constrs, err := m.AddConstrsCSR(
+		xs, []int{0, 2, 3}, []int{0, 2, 1}, []float64{1, 3, -2},
+		[]goop.ConstrSense{goop.SenseLessThanEqual, goop.SenseEqual},
+		[]float64{4, 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Duplicate variables are merged and constants move into the bounds
+	m.AddConstr(goop.Between(
+		-1, goop.Sum(xs[0], xs[1], xs[0], goop.K(1)), 5,
+	))
+	m.SetObjective(
+		goop.Sum(xs[1].Mult(2), xs[1], goop.K(7)), goop.SenseMaximize,
+	)
+
+	mf, err := m.MatrixForm()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equalInts(t, "RowPtr", mf.RowPtr, []int{0, 2, 3, 5})
+	equalInts(t, "ColIdx", mf.ColIdx, []int{0, 2, 1, 0, 1})
+	equalFloats(t, "Vals", mf.Vals, []float64{1, 3, -2, 2, 1})
+	equalInts(t, "ColPtr", mf.ColPtr, []int{0, 2, 4, 5})
+	equalInts(t, "RowIdx", mf.RowIdx, []int{0, 2, 1, 2, 0})
+	equalFloats(t, "ColVals", mf.ColVals, []float64{1, 2, -2, 1, 3})
+	equalFloats(t, "RowLower", mf.RowLower, []float64{math.Inf(-1), 1, -2})
+	equalFloats(t, "RowUpper", mf.RowUpper, []float64{4, 1, 4})
+	equalFloats(t, "Obj", mf.Obj, []float64{0, 3, 0})
+
+	if mf.ObjOffset != 7 || mf.ObjSense != goop.SenseMaximize {
+		t.Errorf("Objective mismatch: %v %v", mf.ObjOffset, mf.ObjSense)
+	}
+
+	if mf.Integer[0] || !mf.Integer[2] || !math.IsInf(mf.ColUpper[1], 1) {
+		t.Error("Column mismatch")
+	}
+
+	if mf.Vars[1] != xs[1] || mf.Constrs[1] != constrs[1] {
+		t.Error("Mapping mismatch")
+	}
+
+	m.AddMax(xs[0], xs[1])
+	if _, err := m.MatrixForm(); err == nil {
+		t.Error("Expected error for general constraint")
+	}
+}
+
+func equalInts(t *testing.T, name string, actual, expected []int) {
+	if len(actual) != len(expected) {
+		t.Errorf("%s mismatch: %v != %v", name, actual, expected)
+		return
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("%s mismatch: %v != %v", name, actual, expected)
+			return
+		}
+	}
+}
+
+func equalFloats(t *testing.T, name string, actual, expected []float64) {
+	if len(actual) != len(expected) {
+		t.Errorf("%s mismatch: %v != %v", name, actual, expected)
+		return
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("%s mismatch: %v != %v", name, actual, expected)
+			return
+		}
+	}
+}
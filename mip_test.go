@@ -1,7 +1,10 @@
 package goop_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/mit-drl/goop"
@@ -74,3 +77,780 @@ func prettyPrintVarMatrix(vs [][]*goop.Var, sol *goop.Solution) string {
 
 	return matStr
 }
+
+func solveMaxModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(3, 10, goop.Continuous)
+	y := m.AddVar(1, 10, goop.Continuous)
+	z := m.AddMax(x, y)
+
+	m.SetObjective(z, goop.SenseMinimize)
+	sol, err := m.Optimize(solver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Value(z)-3) > 1e-6 {
+		t.Errorf("max(x, y) = %v, expected 3", sol.Value(z))
+	}
+}
+
+func solveMinModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	z := m.AddMin(x, y)
+
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(10)))
+	m.SetObjective(z, goop.SenseMaximize)
+	sol, err := m.Optimize(solver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Value(z)-5) > 1e-6 {
+		t.Errorf("min(x, y) = %v, expected 5", sol.Value(z))
+	}
+}
+
+func solveAbsModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(-4, 3, goop.Integer)
+	y := m.AddAbs(x)
+
+	// Maximizing an absolute value is not convex so the big-M formulation
+	// must be used to keep y from reaching the bound of 4 implied by x
+	m.AddConstr(x.GreaterEq(goop.K(-2)))
+	m.SetObjective(y, goop.SenseMaximize)
+	sol, err := m.Optimize(solver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Value(y)-3) > 1e-6 || math.Abs(sol.Value(x)-3) > 1e-6 {
+		t.Errorf(
+			"|x| = %v with x = %v, expected 3", sol.Value(y), sol.Value(x),
+		)
+	}
+}
+
+func solveSemiContinuousModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(50, 400, goop.SemiContinuous)
+	y := m.AddVar(50, 400, goop.SemiContinuous)
+	z := m.AddVar(3, 8, goop.SemiInteger)
+
+	m.AddConstr(x.GreaterEq(goop.K(10)))
+	m.AddConstr(z.GreaterEq(goop.K(1.5)))
+	m.SetObjective(goop.Sum(x, y, z), goop.SenseMinimize)
+	sol, err := m.Optimize(solver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[*goop.Var]float64{x: 50, y: 0, z: 3}
+	for v, val := range expected {
+		if math.Abs(sol.Value(v)-val) > 1e-6 {
+			t.Errorf("Value mismatch: %v != %v", sol.Value(v), val)
+		}
+	}
+}
+
+func solveRangeModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+
+	m.AddConstr(goop.Between(2, goop.Sum(x, goop.K(1)), 6))
+	m.AddConstr(goop.Between(1, y, 3))
+	m.SetObjective(goop.Sum(x, y.Mult(-1)), goop.SenseMinimize)
+	sol, err := m.Optimize(solver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Value(x)-1) > 1e-6 || math.Abs(sol.Value(y)-3) > 1e-6 {
+		t.Errorf(
+			"x = %v and y = %v, expected 1 and 3", sol.Value(x), sol.Value(y),
+		)
+	}
+}
+
+func solveUnboundedModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddFreeVar(goop.Continuous)
+	y := m.AddNonNegVar(goop.Continuous)
+
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(10)))
+	m.SetObjective(x, goop.SenseMinimize)
+	sol, err := m.Optimize(solver)
+
+	if err != goop.ErrUnbounded {
+		t.Fatalf("Expected unbounded error, got %v", err)
+	}
+
+	if sol.Status != goop.StatusUnbounded {
+		t.Errorf("Status mismatch: %v != %v", sol.Status, goop.StatusUnbounded)
+	}
+
+	if !math.IsInf(sol.Objective, -1) {
+		t.Errorf("Objective mismatch: %v != -Inf", sol.Objective)
+	}
+}
+
+// checkSessionObjective optimizes the session and checks the objective of
+// its solution
+func checkSessionObjective(t *testing.T, sess *goop.Session, expected float64) {
+	t.Helper()
+
+	sol, err := sess.Optimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-expected) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != %v", sol.Objective, expected)
+	}
+}
+
+func solveSessionModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(8)))
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+
+	sess := m.Attach(solver)
+	checkSessionObjective(t, sess, 16)
+
+	// Only the new constraint is sent to the solver
+	m.AddConstr(y.LessEq(goop.K(3)))
+	checkSessionObjective(t, sess, 11)
+
+	// New variables can be used in new constraints and the objective
+	z := m.AddVar(0, 10, goop.Continuous)
+	m.AddConstr(goop.Sum(x, z).LessEq(goop.K(6)))
+	m.SetObjective(goop.Sum(x, y.Mult(2), z.Mult(3)), goop.SenseMaximize)
+	checkSessionObjective(t, sess, 24)
+}
+
+func solveMutableVarsModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(12)))
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+
+	sess := m.Attach(solver)
+	checkSessionObjective(t, sess, 22)
+
+	m.Fix(y, 4)
+	checkSessionObjective(t, sess, 16)
+
+	m.Unfix(y)
+	m.SetBounds(x, 0, 1)
+	checkSessionObjective(t, sess, 21)
+
+	m.SetObjCoeff(y, -1)
+	checkSessionObjective(t, sess, 1)
+
+	m.SetType(x, goop.Binary)
+	m.SetObjCoeff(x, 0.5)
+	checkSessionObjective(t, sess, 0.5)
+}
+
+func solveRemoveModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	s := m.AddVar(2, 5, goop.SemiContinuous)
+	c1 := goop.Sum(x, y).LessEq(goop.K(8))
+	c2 := goop.Sum(x, s).LessEq(goop.K(6))
+	m.AddConstr(c1)
+	m.AddConstr(c2)
+	m.SetObjective(goop.Sum(x, y.Mult(2), s), goop.SenseMaximize)
+
+	sess := m.Attach(solver)
+	checkSessionObjective(t, sess, 21)
+
+	// Renaming a variable does not change it in the solver
+	m.SetName(s, "s")
+	checkSessionObjective(t, sess, 21)
+
+	if err := m.RemoveConstr(c1); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSessionObjective(t, sess, 26)
+
+	// Optimizing fails while a constraint still uses a removed variable
+	if err := m.RemoveVar(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sess.Optimize(); err == nil {
+		t.Error("Removed variable in constraint: no error returned")
+	}
+
+	if err := m.RemoveConstr(c2); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+	checkSessionObjective(t, sess, 30)
+
+	// Every change is validated before it is sent to the solver
+	nan := x.Mult(math.NaN()).LessEq(goop.K(1))
+	m.AddConstr(nan)
+	if _, err := sess.Optimize(); err == nil {
+		t.Error("NaN coefficient: no error returned")
+	}
+
+	if err := m.RemoveConstr(nan); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSessionObjective(t, sess, 30)
+}
+
+func solveLexicographicModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(10)))
+
+	// The objective of higher priority is optimized first even though it is
+	// added last and may degrade by up to 10% once it is optimized
+	m.AddObjective(x, goop.SenseMaximize, 1, 0, 0)
+	m.AddObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize, 2, 0.1, 0)
+
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []float64{18, 2}
+	if len(sol.Objectives) != len(expected) {
+		t.Fatalf("Objectives mismatch: %v != %v", sol.Objectives, expected)
+	}
+
+	for i := range expected {
+		if math.Abs(sol.Objectives[i]-expected[i]) > 1e-6 {
+			t.Errorf("Objectives mismatch: %v != %v", sol.Objectives, expected)
+		}
+	}
+
+	if math.Abs(sol.Value(x)-2) > 1e-6 || math.Abs(sol.Value(y)-8) > 1e-6 {
+		t.Errorf(
+			"Solution mismatch: (%v, %v) != (2, 8)",
+			sol.Value(x), sol.Value(y),
+		)
+	}
+
+	// The objective is the value of the objective optimized first, also when
+	// presolve removes every variable
+	for _, fixed := range []bool{false, true} {
+		if fixed {
+			m.Fix(x, 2)
+			m.Fix(y, 8)
+		}
+
+		sol, err := m.Optimize(solver)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if math.Abs(sol.Objective-18) > 1e-6 {
+			t.Errorf("Objective mismatch: %v != 18", sol.Objective)
+		}
+	}
+}
+
+func solveParetoModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	z := m.AddVar(0, 2, goop.Integer)
+	m.AddConstr(goop.Sum(x, y).GreaterEq(goop.K(10)))
+	m.AddConstr(y.Eq(z.Mult(5)))
+
+	// Several epsilon values lead to the same solution, which must only be
+	// returned once
+	front, err := goop.ParetoFront(m, []goop.Objective{
+		*goop.NewObjective(x, goop.SenseMinimize),
+		*goop.NewObjective(y, goop.SenseMinimize),
+	}, goop.ParetoOptions{Solver: solver, NumPoints: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]float64{{0, 10}, {5, 5}, {10, 0}}
+	if len(front) != len(expected) {
+		t.Fatalf(
+			"Number of solutions mismatch: %v != %v",
+			len(front), len(expected),
+		)
+	}
+
+	for i, sol := range front {
+		for j := range expected[i] {
+			if math.Abs(sol.Objectives[j]-expected[i][j]) > 1e-6 {
+				t.Errorf(
+					"Objectives mismatch: %v != %v",
+					sol.Objectives, expected[i],
+				)
+			}
+		}
+	}
+}
+
+func solvePoolModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	xs := m.AddBinaryVarVector(3)
+	m.AddConstr(goop.SumVars(xs...).LessEq(goop.K(2)))
+	m.SetObjective(
+		goop.Sum(xs[0].Mult(3), xs[1].Mult(2), xs[2]), goop.SenseMaximize,
+	)
+
+	// The best solutions differing in at least two variables are {0, 1},
+	// {0, 2} and {1, 2}, but the last one is outside the allowed gap. With a
+	// distance of one, the native pool is used if the solver has one, and the
+	// absolute gap applies since it is larger than the relative gap.
+	for _, opts := range []goop.PoolOptions{
+		{MinDistance: 2, AbsGap: 1},
+		{MinDistance: 1, RelGap: 0.1, AbsGap: 1},
+	} {
+		sols, err := m.OptimizeN(solver, 5, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []float64{5, 4}
+		if len(sols) != len(expected) {
+			t.Fatalf(
+				"Number of solutions mismatch: %v != %v",
+				len(sols), len(expected),
+			)
+		}
+
+		for i, sol := range sols {
+			if math.Abs(sol.Objective-expected[i]) > 1e-6 {
+				t.Errorf(
+					"Objective mismatch: %v != %v", sol.Objective, expected[i],
+				)
+			}
+		}
+	}
+}
+
+func solveProgressModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	xs := m.AddVarVector(4, 0, 10, goop.Integer)
+	m.AddConstr(goop.SumVars(xs...).LessEq(goop.K(7.5)))
+	m.SetObjective(
+		goop.Sum(xs[0], xs[1].Mult(2), xs[2].Mult(3), xs[3].Mult(4)),
+		goop.SenseMaximize,
+	)
+
+	// Aborting at the first report returns the incumbent found so far
+	numEvents := 0
+	m.OnProgress(func(e goop.ProgressEvent) bool {
+		numEvents++
+		unknown := math.IsNaN(e.Incumbent+e.BestBound) ||
+			math.IsInf(e.Incumbent, 0) || math.IsInf(e.BestBound, 0)
+		if unknown != math.IsNaN(e.Gap) || e.Gap < 0 {
+			t.Errorf(
+				"Gap mismatch: %v for incumbent %v and bound %v",
+				e.Gap, e.Incumbent, e.BestBound,
+			)
+		}
+
+		return false
+	})
+
+	sol, err := m.Optimize(solver)
+	if numEvents == 0 {
+		t.Fatal("Progress function was not called")
+	}
+
+	// The solve stops before optimality is proven, with or without an
+	// incumbent
+	switch {
+	case err == goop.ErrNoSolution:
+	case err != nil:
+		t.Fatal(err)
+	case sol.Status != goop.StatusSuboptimal:
+		t.Errorf("Status mismatch: %v != %v", sol.Status, goop.StatusSuboptimal)
+	}
+}
+
+func solveContextModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Integer)
+	y := m.AddVar(0, 10, goop.Integer)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(7.5)))
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+	sess := m.Attach(solver)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sess.OptimizeContext(ctx); err != context.Canceled {
+		t.Errorf("Error mismatch: %v != %v", err, context.Canceled)
+	}
+
+	// The solver can still be used once the context is done
+	sol, err := sess.OptimizeContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-14) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 14", sol.Objective)
+	}
+}
+
+func solveParamsModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Integer)
+	y := m.AddVar(0, 10, goop.Integer)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(7.5)))
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+
+	m.SetParam(goop.ParamMIPGap, 0)
+	m.SetParam(goop.ParamMIPGapAbs, 0)
+	m.SetParam(goop.ParamIntFeasTol, 1e-6)
+
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-14) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 14", sol.Objective)
+	}
+
+}
+
+func solveSolverPresolveModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	c := goop.Sum(x, y).LessEq(goop.K(8))
+	m.AddConstr(c)
+	m.AddConstr(x.LessEq(goop.K(3)))
+	m.AddConstr(y.LessEq(goop.K(20)))
+	m.SetObjective(goop.Sum(x, y.Mult(2)), goop.SenseMaximize)
+	m.SetParam(goop.ParamPresolve, 1)
+
+	if !solver.SupportsParam(int(goop.ParamPresolve)) {
+		if _, err := m.Optimize(solver); err == nil {
+			t.Error("Unsupported presolve parameter: no error returned")
+		}
+
+		return
+	}
+
+	// Rows removed by the solver's presolve must not shift the rows of the
+	// session
+	sess := m.Attach(solver)
+	checkSessionObjective(t, sess, 16)
+
+	if err := m.RemoveConstr(c); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSessionObjective(t, sess, 23)
+}
+
+func solvePresolveModel(t *testing.T, newSolver func() solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Integer)
+	fixed := m.AddVar(3, 3, goop.Continuous)
+	unused := m.AddVar(-5, 5, goop.Continuous)
+	rounded := m.AddVar(0.5, 3.5, goop.Integer)
+
+	// The singleton row becomes a bound and the fixed variable is substituted
+	m.AddConstr(y.Mult(2).LessEq(goop.K(9)))
+	m.AddConstr(goop.Sum(x, y, fixed).LessEq(goop.K(10)))
+	m.SetObjective(
+		goop.Sum(x, y.Mult(2), fixed, unused.Mult(-1)), goop.SenseMaximize,
+	)
+
+	sol, err := m.Optimize(newSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-19) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 19", sol.Objective)
+	}
+
+	// Removed integer variables take the integer value closest to zero
+	expected := map[*goop.Var]float64{
+		x: 3, y: 4, fixed: 3, unused: -5, rounded: 1,
+	}
+	for v, val := range expected {
+		if math.Abs(sol.Value(v)-val) > 1e-6 {
+			t.Errorf("Value mismatch: %v != %v", sol.Value(v), val)
+		}
+	}
+
+	// Integer variables without an integer value in their bounds are
+	// infeasible
+	m.SetBounds(rounded, 2.5, 2.5)
+	if _, err := m.Optimize(newSolver()); err != goop.ErrInfeasible {
+		t.Errorf("Error mismatch: %v != %v", err, goop.ErrInfeasible)
+	}
+
+	// Trivially infeasible models are detected without solving
+	m.SetBounds(rounded, 0.5, 3.5)
+	m.AddConstr(goop.Sum(x, y).GreaterEq(goop.K(20)))
+	if _, err := m.Optimize(newSolver()); err != goop.ErrInfeasible {
+		t.Errorf("Error mismatch: %v != %v", err, goop.ErrInfeasible)
+	}
+}
+
+func solveJSONModel(t *testing.T, newSolver func() solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Integer)
+	m.SetName(x, "x")
+	m.AddConstr(goop.Sum(x, y.Mult(2)).LessEq(goop.K(13)))
+	m.AddConstr(goop.Between(1, goop.Sum(x, y.Mult(-1)), 4))
+	m.SetObjective(goop.Sum(x, y), goop.SenseMaximize)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := goop.NewModel()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	sol, err := m.Optimize(newSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedSol, err := decoded.Optimize(newSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-decodedSol.Objective) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != %v",
+			sol.Objective, decodedSol.Objective)
+	}
+
+	solData, err := json.Marshal(sol)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values of unnamed variables are bound by their index in the model
+	restored, err := m.UnmarshalSolution(solData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []*goop.Var{x, y} {
+		if math.Abs(restored.Value(v)-sol.Value(v)) > 1e-6 {
+			t.Errorf("Value mismatch: %v != %v",
+				restored.Value(v), sol.Value(v))
+		}
+	}
+}
+
+func solveCSRModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	xs := m.AddVarVector(3, 0, 10, goop.Integer)
+
+	// x0 + x1 + x2 <= 7, x0 - x1 >= 1 and 2 x1 + x2 = 6 in CSR form
+	_, err := m.AddConstrsCSR(
+		xs, []int{0, 3, 5, 7}, []int{0, 1, 2, 0, 1, 1, 2},
+		[]float64{1, 1, 1, 1, -1, 2, 1},
+		[]goop.ConstrSense{
+			goop.SenseLessThanEqual,
+			goop.SenseGreaterThanEqual,
+			goop.SenseEqual,
+		},
+		[]float64{7, 1, 6},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.AddConstrsDense(
+		xs, [][]float64{{0, 0, 1}}, []goop.ConstrSense{goop.SenseLessThanEqual},
+		[]float64{4},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.SetObjective(goop.Dot(xs, []float64{3, 2, 1}), goop.SenseMaximize)
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-18) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 18", sol.Objective)
+	}
+}
+
+func solveValuesModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	xs := m.AddVarMatrix(2, 2, 0, 10, goop.Integer)
+
+	// Every row sums to 5 and the first column is fixed by the objective
+	m.AddConstr(goop.SumRow(xs, 0).Eq(goop.K(5)))
+	m.AddConstr(goop.SumRow(xs, 1).Eq(goop.K(5)))
+	m.SetObjective(goop.SumCol(xs, 1), goop.SenseMinimize)
+
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vals := sol.ValuesMatrix(xs)
+	expected := [][]float64{{5, 0}, {5, 0}}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(vals[i][j]-expected[i][j]) > 1e-6 {
+				t.Errorf("Value mismatch: %v != %v", vals[i][j],
+					expected[i][j])
+			}
+		}
+	}
+
+	if row := sol.Values(xs[1]); len(row) != 2 || row[0] != vals[1][0] {
+		t.Errorf("Values mismatch: %v != %v", row, vals[1])
+	}
+}
+
+func solveLifecycleModel(t *testing.T, newSolver func() solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Integer)
+	y := m.AddVar(0, 10, goop.Integer)
+	m.AddConstr(goop.Sum(x, y).LessEq(goop.K(8)))
+	m.SetObjective(goop.Sum(x.Mult(2), y), goop.SenseMaximize)
+
+	solver := goop.NewSolver(newSolver())
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-16) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 16", sol.Objective)
+	}
+
+	// The solver can be reused and holds only the latest model
+	m.SetBounds(x, 0, 3)
+	sol, err = m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-11) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 11", sol.Objective)
+	}
+
+	if err := solver.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Optimize(solver); err != goop.ErrSolverClosed {
+		t.Errorf("Error mismatch: %v != %v", err, goop.ErrSolverClosed)
+	}
+
+	if _, err := m.Attach(solver).Optimize(); err != goop.ErrSolverClosed {
+		t.Errorf("Error mismatch: %v != %v", err, goop.ErrSolverClosed)
+	}
+
+	if err := solver.Close(); err != nil {
+		t.Errorf("Closing twice returned %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Using a closed solver directly did not panic")
+		}
+	}()
+
+	solver.Infinity()
+}
+
+func solveBoundedMaxModel(t *testing.T, newSolver func() solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	z := m.AddMax(x, y)
+
+	// z = max(x, y) >= 5 forces x or y to be at least 5, which the epigraph
+	// form z >= x, z >= y alone does not
+	m.AddConstr(z.GreaterEq(goop.K(5)))
+	m.SetObjective(goop.Sum(z, x, y), goop.SenseMinimize)
+
+	sol, err := m.Optimize(newSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-10) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 10", sol.Objective)
+	}
+
+	// The same holds for a bound on the result set without a constraint
+	m = goop.NewModel()
+	x = m.AddVar(0, 10, goop.Continuous)
+	y = m.AddVar(0, 10, goop.Continuous)
+	z = m.AddMax(x, y)
+	m.SetBounds(z, 5, 10)
+	m.SetObjective(goop.Sum(z, x, y), goop.SenseMinimize)
+
+	sol, err = m.Optimize(newSolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Objective-10) > 1e-6 {
+		t.Errorf("Objective mismatch: %v != 10", sol.Objective)
+	}
+}
+
+func solveWidenedMaxModel(t *testing.T, solver solvers.Solver) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 5, goop.Continuous)
+	z := m.AddMax(x, y)
+	m.SetObjective(x, goop.SenseMaximize)
+
+	// Widening an expression after adding the max also widens the max
+	m.SetBounds(x, 0, 20)
+	sol, err := m.Optimize(solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(sol.Value(z)-20) > 1e-6 {
+		t.Errorf("max(x, y) = %v, expected 20", sol.Value(z))
+	}
+
+	// Sessions rebuild the big-M constraints when the bounds change
+	m.SetBounds(x, 0, 10)
+	sess := m.Attach(solver)
+	checkSessionObjective(t, sess, 10)
+
+	m.SetBounds(x, 0, 20)
+	checkSessionObjective(t, sess, 20)
+}
@@ -1,13 +1,14 @@
 package goop
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/mit-drl/goop/solvers"
-	"github.com/sirupsen/logrus"
 )
 
 // Model represents the overall constrained linear optimization model to be
@@ -15,17 +16,35 @@ import (
 // problem, constraints, objective, and parameters. New variables can only be
 // created using an instantiated Model.
 type Model struct {
-	vars      []*Var
-	constrs   []*Constr
-	obj       *Objective
-	showLog   bool
-	timeLimit time.Duration
+	vars       []*Var
+	byID       map[uint64]*Var
+	constrs    []*Constr
+	genConstrs []*genConstr
+	results    map[uint64]*genConstr
+	obj        *Objective
+	objs       []*prioritizedObjective
+	showLog    bool
+	timeLimit  time.Duration
+	onProgress func(ProgressEvent) bool
+	params     map[Param]float64
+	noPresolve bool
+	printOpts  PrintOptions
+
+	// unfixed holds the bounds of fixed variables from before they were
+	// fixed, keyed by variable ID
+	unfixed map[uint64]varBounds
+}
+
+// varBounds holds the lower and upper value limits of a variable
+type varBounds struct {
+	lower float64
+	upper float64
 }
 
 // NewModel returns a new model with some default arguments such as not to show
 // the log and no time limit.
 func NewModel() *Model {
-	return &Model{showLog: false}
+	return &Model{showLog: false, byID: make(map[uint64]*Var)}
 }
 
 // ShowLog instructs the solver to show the log or not.
@@ -39,14 +58,27 @@ func (m *Model) SetTimeLimit(dur time.Duration) {
 }
 
 // AddVar adds a variable of a given variable type to the model given the lower
-// and upper value limits. This variable is returned.
+// and upper value limits. This variable is returned. Use -Inf and Inf for
+// variables that are unbounded below or above.
 func (m *Model) AddVar(lower, upper float64, vtype VarType) *Var {
-	id := uint64(len(m.vars))
-	newVar := &Var{id, lower, upper, vtype}
+	newVar := &Var{id: newVarID(), lower: lower, upper: upper, vtype: vtype}
 	m.vars = append(m.vars, newVar)
+	m.byID[newVar.ID()] = newVar
 	return newVar
 }
 
+// AddFreeVar adds a variable of a given variable type to the model with no
+// lower or upper limits and returns said variable.
+func (m *Model) AddFreeVar(vtype VarType) *Var {
+	return m.AddVar(-Inf, Inf, vtype)
+}
+
+// AddNonNegVar adds a variable of a given variable type to the model that is
+// non-negative and unbounded above and returns said variable.
+func (m *Model) AddNonNegVar(vtype VarType) *Var {
+	return m.AddVar(0, Inf, vtype)
+}
+
 // AddBinaryVar adds a binary variable to the model and returns said variable.
 func (m *Model) AddBinaryVar() *Var {
 	return m.AddVar(0, 1, Binary)
@@ -57,13 +89,11 @@ func (m *Model) AddBinaryVar() *Var {
 func (m *Model) AddVarVector(
 	num int, lower, upper float64, vtype VarType,
 ) []*Var {
-	stID := uint64(len(m.vars))
 	vs := make([]*Var, num)
 	for i := range vs {
-		vs[i] = &Var{stID + uint64(i), lower, upper, vtype}
+		vs[i] = m.AddVar(lower, upper, vtype)
 	}
 
-	m.vars = append(m.vars, vs...)
 	return vs
 }
 
@@ -92,80 +122,292 @@ func (m *Model) AddBinaryVarMatrix(rows, cols int) [][]*Var {
 	return m.AddVarMatrix(rows, cols, 0, 1, Binary)
 }
 
+// SetBounds changes the lower and upper value limits of a variable in the
+// model. If the variable was fixed using Fix, it is no longer considered
+// fixed. The change takes effect on the next call to Optimize. An error is
+// returned if the variable is not part of the model, for example because it
+// was removed.
+func (m *Model) SetBounds(v *Var, lower, upper float64) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	delete(m.unfixed, v.ID())
+	v.lower = lower
+	v.upper = upper
+	return nil
+}
+
+// SetType changes the type of a variable in the model. Changing the type to
+// Binary also sets the bounds of the variable to 0 and 1. The change takes
+// effect on the next call to Optimize. An error is returned if the variable
+// is not part of the model.
+func (m *Model) SetType(v *Var, vtype VarType) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	if vtype == Binary {
+		m.SetBounds(v, 0, 1)
+	}
+
+	v.vtype = vtype
+	return nil
+}
+
+// SetName sets the name of a variable in the model, which is used when the
+// model is printed. An error is returned if the variable is not part of the
+// model.
+func (m *Model) SetName(v *Var, name string) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	v.name = name
+	return nil
+}
+
+// Fix fixes a variable in the model to the given value by setting both of its
+// bounds to the value. The original bounds can be restored using Unfix. An
+// error is returned if the variable is not part of the model.
+func (m *Model) Fix(v *Var, val float64) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	if _, ok := m.unfixed[v.ID()]; !ok {
+		if m.unfixed == nil {
+			m.unfixed = make(map[uint64]varBounds)
+		}
+
+		m.unfixed[v.ID()] = varBounds{v.Lower(), v.Upper()}
+	}
+
+	v.lower = val
+	v.upper = val
+	return nil
+}
+
+// Unfix restores the bounds a variable had before it was fixed using Fix. It
+// has no effect if the variable is not fixed. An error is returned if the
+// variable is not part of the model.
+func (m *Model) Unfix(v *Var) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	if bounds, ok := m.unfixed[v.ID()]; ok {
+		return m.SetBounds(v, bounds.lower, bounds.upper)
+	}
+
+	return nil
+}
+
+// SetObjCoeff sets the coefficient of a variable in the objective, replacing
+// any existing terms of the variable. If the model has no objective, a new
+// objective to minimize is created. The change takes effect on the next call
+// to Optimize. An error is returned if the variable is not part of the model.
+func (m *Model) SetObjCoeff(v *Var, coeff float64) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	if m.obj == nil {
+		m.SetObjective(NewExpr(0), SenseMinimize)
+	}
+
+	newExpr := &LinearExpr{constant: m.obj.Constant()}
+	coeffs := m.obj.Coeffs()
+	for i, id := range m.obj.Vars() {
+		if id != v.ID() {
+			newExpr.vars = append(newExpr.vars, id)
+			newExpr.coeffs = append(newExpr.coeffs, coeffs[i])
+		}
+	}
+
+	if coeff != 0 {
+		newExpr.vars = append(newExpr.vars, v.ID())
+		newExpr.coeffs = append(newExpr.coeffs, coeff)
+	}
+
+	m.SetObjective(newExpr, m.obj.sense)
+	return nil
+}
+
+// own returns an error if the variable is not part of the model
+func (m *Model) own(v *Var) error {
+	if m.byID[v.ID()] != v {
+		return fmt.Errorf("variable %d is not part of the model", v.ID())
+	}
+
+	return nil
+}
+
+// RemoveVar removes a variable from the model. Any constraints or objective
+// that still use the variable must be removed or replaced before the model is
+// optimized again, otherwise Optimize returns an error. An error is returned
+// if the variable is not part of the model, for example because it was
+// already removed, or if it is used by a general constraint, such as the
+// result of AddMax or one of its arguments, since general constraints cannot
+// be removed.
+func (m *Model) RemoveVar(v *Var) error {
+	if err := m.own(v); err != nil {
+		return err
+	}
+
+	for _, gc := range m.genConstrs {
+		if gc.uses(v.ID()) {
+			return fmt.Errorf(
+				"variable %d is used by a general constraint", v.ID(),
+			)
+		}
+	}
+
+	for i, other := range m.vars {
+		if other == v {
+			m.vars = append(m.vars[:i], m.vars[i+1:]...)
+			break
+		}
+	}
+
+	delete(m.byID, v.ID())
+	delete(m.unfixed, v.ID())
+	return nil
+}
+
 // AddConstr adds a the given constraint to the model.
 func (m *Model) AddConstr(constr *Constr) {
 	m.constrs = append(m.constrs, constr)
 }
 
+// RemoveConstr removes a constraint that was previously added to the model.
+// An error is returned if the constraint is not part of the model.
+func (m *Model) RemoveConstr(constr *Constr) error {
+	for i, other := range m.constrs {
+		if other == constr {
+			m.constrs = append(m.constrs[:i], m.constrs[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("constraint is not part of the model")
+}
+
 // SetObjective sets the objective of the model given an expression and
 // objective sense.
 func (m *Model) SetObjective(e Expr, sense ObjSense) {
 	m.obj = NewObjective(e, sense)
 }
 
+// AddObjective adds an objective to a hierarchical model. Once objectives are
+// added, Optimize optimizes them in order of decreasing priority, with ties
+// broken by the order in which they were added, and ignores the objective set
+// using SetObjective. After each stage, the objective that was just optimized
+// is kept within max(relTol * |z|, absTol) of its optimal value z by a
+// constraint while the objectives of lower priority are optimized.
+func (m *Model) AddObjective(
+	e Expr, sense ObjSense, priority int, relTol, absTol float64,
+) {
+	m.objs = append(m.objs, &prioritizedObjective{
+		NewObjective(e, sense), priority, relTol, absTol,
+	})
+}
+
+// stages returns the objectives added using AddObjective in the order in
+// which they are optimized
+func (m *Model) stages() []*prioritizedObjective {
+	stages := make([]*prioritizedObjective, len(m.objs))
+	copy(stages, m.objs)
+	sort.SliceStable(stages, func(i, j int) bool {
+		return stages[i].priority > stages[j].priority
+	})
+
+	return stages
+}
+
 // Optimize optimizes the model using the given solver type and returns the
-// solution or an error.
+// solution or an error. If the model is infeasible or unbounded, or the
+// solver stops without finding a solution, the solution is returned along
+// with an error such as ErrInfeasible or ErrUnbounded describing its status.
+// The solver is not deleted and can be used again, in which case the model
+// it holds is replaced while settings such as a time limit or parameters that
+// are not set again stay in effect. The caller owns the solver and must
+// delete it using solvers.DeleteSolver, or wrap it using NewSolver so that it
+// is deleted once closed. Use Attach to optimize the model repeatedly while
+// only sending the changes to the solver.
 func (m *Model) Optimize(solver solvers.Solver) (*Solution, error) {
-	if len(m.vars) == 0 {
-		return nil, errors.New("no variables in model")
-	}
+	return m.optimizePresolved(solver, (*Session).Optimize)
+}
 
-	lbs := make([]float64, len(m.vars))
-	ubs := make([]float64, len(m.vars))
-	types := new(bytes.Buffer)
-	for i, v := range m.vars {
-		lbs[i] = v.Lower()
-		ubs[i] = v.Upper()
-		types.WriteByte(byte(v.Type()))
+// OptimizeContext is like Optimize but stops the solver when the context is
+// cancelled or its deadline passes. In that case the best solution found so
+// far, if any, is returned along with ctx.Err().
+func (m *Model) OptimizeContext(
+	ctx context.Context, solver solvers.Solver,
+) (*Solution, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	solver.ShowLog(m.showLog)
+	return m.optimizePresolved(solver, func(s *Session) (*Solution, error) {
+		return s.OptimizeContext(ctx)
+	})
+}
 
-	if m.timeLimit > 0 {
-		solver.SetTimeLimit(m.timeLimit.Seconds())
+// optimizePresolved validates the model, presolves it unless presolve is
+// turned off and optimizes the presolved model in a new session using the
+// given function. The values of the variables removed by presolve are added
+// to the solution.
+func (m *Model) optimizePresolved(
+	solver solvers.Solver, optimize func(*Session) (*Solution, error),
+) (*Solution, error) {
+	if err := checkSolver(solver); err != nil {
+		return nil, err
 	}
 
-	solver.AddVars(len(m.vars), &lbs[0], &ubs[0], types.String())
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
 
-	for _, constr := range m.constrs {
-		solver.AddConstr(
-			constr.lhs.NumVars(),
-			getCoeffsPtr(constr.lhs),
-			getVarsPtr(constr.lhs),
-			constr.lhs.Constant(),
-			constr.rhs.NumVars(),
-			getCoeffsPtr(constr.rhs),
-			getVarsPtr(constr.rhs),
-			constr.rhs.Constant(),
-			byte(constr.sense),
-		)
+	if m.noPresolve {
+		return optimize(m.Attach(solver))
 	}
 
+	reduced, fixed := m.presolve()
+
+	sense := SenseMinimize
 	if m.obj != nil {
-		logrus.WithField(
-			"num_vars", m.obj.NumVars(),
-		).Info("Number of variables in objective")
-		solver.SetObjective(
-			m.obj.NumVars(),
-			getCoeffsPtr(m.obj),
-			getVarsPtr(m.obj),
-			m.obj.Constant(),
-			int(m.obj.sense),
-		)
-	}
-
-	mipSol := solver.Optimize()
-	defer solvers.DeleteSolver(solver)
-
-	if mipSol.GetErrorCode() != 0 {
-		msg := fmt.Sprintf(
-			"[Code = %d] %s",
-			mipSol.GetErrorCode(),
-			mipSol.GetErrorMessage(),
-		)
-		return nil, errors.New(msg)
-	}
-
-	sol := newSolution(mipSol)
-	return sol, nil
+		sense = m.obj.sense
+	}
+
+	if reduced == nil {
+		return &Solution{
+			Objective: math.Inf(int(sense)),
+			Status:    StatusInfeasible,
+		}, ErrInfeasible
+	}
+
+	// Every variable was removed, so the solution is known without solving
+	if len(reduced.vars) == 0 && len(m.vars) > 0 {
+		sol := &Solution{
+			fixed:   fixed,
+			Optimal: true,
+			Status:  StatusOptimal,
+		}
+		sol.setVars(m.vars)
+		if reduced.obj != nil {
+			sol.Objective = reduced.obj.Constant()
+		}
+
+		sol.setStages(reduced.stages())
+		return sol, nil
+	}
+
+	sol, err := optimize(reduced.Attach(solver))
+	if sol != nil {
+		sol.fixed = fixed
+		sol.setVars(m.vars)
+	}
+
+	return sol, err
 }
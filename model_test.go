@@ -0,0 +1,117 @@
+package goop_test
+
+import (
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestFixUnfix(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(-1, 5, goop.Continuous)
+
+	m.Fix(x, 2)
+	if x.Lower() != 2 || x.Upper() != 2 {
+		t.Errorf("Bounds mismatch: [%v, %v] != [2, 2]", x.Lower(), x.Upper())
+	}
+
+	// Fixing twice must still restore the original bounds
+	m.Fix(x, 3)
+	m.Unfix(x)
+	if x.Lower() != -1 || x.Upper() != 5 {
+		t.Errorf("Bounds mismatch: [%v, %v] != [-1, 5]", x.Lower(), x.Upper())
+	}
+
+	// Setting bounds explicitly discards the original bounds
+	m.Fix(x, 3)
+	m.SetBounds(x, 0, 1)
+	m.Unfix(x)
+	if x.Lower() != 0 || x.Upper() != 1 {
+		t.Errorf("Bounds mismatch: [%v, %v] != [0, 1]", x.Lower(), x.Upper())
+	}
+}
+
+func TestSetTypeBinary(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(-1, 5, goop.Integer)
+	m.SetType(x, goop.Binary)
+
+	if x.Type() != goop.Binary || x.Lower() != 0 || x.Upper() != 1 {
+		t.Errorf(
+			"Var mismatch: %c [%v, %v] != B [0, 1]",
+			x.Type(), x.Lower(), x.Upper(),
+		)
+	}
+}
+
+func TestMutateForeignVar(t *testing.T) {
+	m := goop.NewModel()
+	other := goop.NewModel()
+	m.AddBinaryVar()
+	foreign := other.AddBinaryVar()
+	removed := m.AddBinaryVar()
+	if err := m.RemoveVar(removed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []*goop.Var{foreign, removed} {
+		errs := []error{
+			m.SetBounds(v, 0, 0),
+			m.SetType(v, goop.Integer),
+			m.SetName(v, "x"),
+			m.Fix(v, 1),
+			m.Unfix(v),
+			m.SetObjCoeff(v, 1),
+		}
+
+		for i, err := range errs {
+			if err == nil {
+				t.Errorf("Variable %d, method %d: no error returned", v.ID(), i)
+			}
+		}
+
+		if v.Lower() != 0 || v.Upper() != 1 || v.Type() != goop.Binary ||
+			v.Name() != "" {
+			t.Errorf("Variable %d was changed", v.ID())
+		}
+	}
+}
+
+func TestRemoveVarConstr(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+	c := x.LessEq(goop.K(1))
+	m.AddConstr(c)
+
+	if err := m.RemoveConstr(c); err != nil {
+		t.Error(err)
+	}
+
+	if err := m.RemoveConstr(c); err == nil {
+		t.Error("Removed constraint twice: no error returned")
+	}
+
+	if err := m.RemoveVar(x); err != nil {
+		t.Error(err)
+	}
+
+	if err := m.RemoveVar(x); err == nil {
+		t.Error("Removed variable twice: no error returned")
+	}
+
+	// IDs of removed variables are never reused
+	y := m.AddBinaryVar()
+	if y.ID() == x.ID() {
+		t.Errorf("ID reused: %v == %v", y.ID(), x.ID())
+	}
+
+	// Variables used by general constraints cannot be removed
+	z := m.AddMax(y, goop.K(0.5))
+	if err := m.RemoveVar(y); err == nil {
+		t.Error("Removed argument of max: no error returned")
+	}
+
+	if err := m.RemoveVar(z); err == nil {
+		t.Error("Removed result of max: no error returned")
+	}
+}
@@ -1,5 +1,7 @@
 package goop
 
+import "math"
+
 // Objective represents an optimization objective given an expression and
 // objective sense (maximize or minimize).
 type Objective struct {
@@ -13,6 +15,31 @@ func NewObjective(e Expr, sense ObjSense) *Objective {
 	return &Objective{e, sense}
 }
 
+// prioritizedObjective is an objective of a hierarchical model along with its
+// priority and the degradation allowed once it has been optimized
+type prioritizedObjective struct {
+	*Objective
+	priority int
+	relTol   float64
+	absTol   float64
+}
+
+// tolerance returns how far the objective may degrade from its optimal value
+// val when optimizing objectives of lower priority
+func (o *prioritizedObjective) tolerance(val float64) float64 {
+	return math.Max(o.relTol*math.Abs(val), o.absTol)
+}
+
+// bound returns the constraint that keeps the objective within its tolerance
+// of its optimal value val
+func (o *prioritizedObjective) bound(val float64) *Constr {
+	if o.sense == SenseMaximize {
+		return o.GreaterEq(K(val - o.tolerance(val)))
+	}
+
+	return o.LessEq(K(val + o.tolerance(val)))
+}
+
 // ObjSense represents whether an optimization objective is to be maximized or
 // minimized. This implementation conforms to the Gurobi encoding
 type ObjSense int
@@ -22,3 +49,8 @@ const (
 	SenseMinimize ObjSense = 1
 	SenseMaximize          = -1
 )
+
+// worse returns true if the objective value a is worse than b
+func (sense ObjSense) worse(a, b float64) bool {
+	return (a-b)*float64(sense) > 0
+}
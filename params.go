@@ -0,0 +1,70 @@
+package goop
+
+import "fmt"
+
+// Param identifies a solver independent parameter. The encoding matches the
+// parameters used by the solvers package.
+type Param int
+
+// Solver independent parameters. Not every solver supports every parameter;
+// optimizing a model with a parameter the solver does not support returns an
+// error.
+const (
+	// ParamMIPGap is the relative MIP optimality gap at which to stop
+	ParamMIPGap Param = iota
+
+	// ParamMIPGapAbs is the absolute MIP optimality gap at which to stop
+	ParamMIPGapAbs
+
+	// ParamThreads is the number of threads used by the solver
+	ParamThreads
+
+	// ParamFeasibilityTol is the tolerance for constraint violations
+	ParamFeasibilityTol
+
+	// ParamIntFeasTol is the tolerance for integer variables to be integral
+	ParamIntFeasTol
+
+	// ParamNodeLimit is the number of branch and bound nodes at which to stop
+	ParamNodeLimit
+
+	// ParamSolutionLimit is the number of feasible solutions at which to stop
+	ParamSolutionLimit
+
+	// ParamSeed is the seed for the random number generator of the solver
+	ParamSeed
+
+	// ParamPresolve turns presolve of the solver on (1) or off (0). It is not
+	// supported by lp_solve, which deletes presolved rows from the model.
+	ParamPresolve
+)
+
+var paramNames = []string{
+	"MIPGap",
+	"MIPGapAbs",
+	"Threads",
+	"FeasibilityTol",
+	"IntFeasTol",
+	"NodeLimit",
+	"SolutionLimit",
+	"Seed",
+	"Presolve",
+}
+
+func (p Param) String() string {
+	if p < 0 || int(p) >= len(paramNames) {
+		return fmt.Sprintf("Param(%d)", int(p))
+	}
+
+	return paramNames[p]
+}
+
+// SetParam sets a solver independent parameter that is passed to the solver
+// when the model is optimized.
+func (m *Model) SetParam(param Param, value float64) {
+	if m.params == nil {
+		m.params = make(map[Param]float64)
+	}
+
+	m.params[param] = value
+}
@@ -0,0 +1,214 @@
+package goop
+
+import (
+	"errors"
+	"math"
+
+	"github.com/mit-drl/goop/solvers"
+)
+
+// ParetoOptions holds the options used to compute a Pareto front
+type ParetoOptions struct {
+	// Solver is used for all solves. As with Model.Optimize, it is owned by
+	// the caller.
+	Solver solvers.Solver
+
+	// NumPoints is the number of epsilon values tried for every secondary
+	// objective. It defaults to 10.
+	NumPoints int
+
+	// Tol is the tolerance used when comparing objective values to remove
+	// dominated solutions. It defaults to 1e-6.
+	Tol float64
+}
+
+// ParetoFront computes an approximation of the Pareto front of the model for
+// the given objectives using the epsilon-constraint method, which is intended
+// for two or three objectives. The first objective is optimized while every
+// other objective is bounded by constraints at NumPoints evenly spaced values
+// between its best and worst value in the payoff table, so NumPoints^(n - 1)
+// models are solved for n objectives. Infeasible combinations of bounds are
+// skipped and dominated solutions are removed. The Objectives field of every
+// returned solution holds its objective vector. The objective set on the model
+// is ignored and the model itself is not modified.
+func ParetoFront(
+	m *Model, objs []Objective, opts ParetoOptions,
+) ([]*Solution, error) {
+	if len(objs) < 2 {
+		return nil, errors.New("a Pareto front requires at least two objectives")
+	}
+
+	if opts.NumPoints <= 0 {
+		opts.NumPoints = 10
+	}
+
+	if opts.Tol <= 0 {
+		opts.Tol = 1e-6
+	}
+
+	s := m.Attach(opts.Solver)
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+
+	// The payoff table holds the value of every objective at the optimum of
+	// every single objective. Its diagonal holds the best value of each
+	// objective and the worst value in each column estimates the nadir.
+	best := make([]float64, len(objs))
+	worst := make([]float64, len(objs))
+	for i := range objs {
+		sol, err := s.optimizeBounded(&objs[i], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range objs {
+			val := sol.eval(&objs[j])
+			if i == j {
+				best[j] = val
+			}
+
+			if i == 0 || objs[j].sense.worse(val, worst[j]) {
+				worst[j] = val
+			}
+		}
+	}
+
+	var front []*Solution
+	grid := make([]int, len(objs)-1)
+	for {
+		bounds := make([]*Constr, len(grid))
+		for i, step := range grid {
+			obj := &objs[i+1]
+			eps := worst[i+1]
+			if opts.NumPoints > 1 {
+				eps += (best[i+1] - worst[i+1]) * float64(step) /
+					float64(opts.NumPoints-1)
+			}
+
+			if obj.sense == SenseMaximize {
+				bounds[i] = obj.GreaterEq(K(eps))
+			} else {
+				bounds[i] = obj.LessEq(K(eps))
+			}
+		}
+
+		sol, err := s.optimizeBounded(&objs[0], bounds)
+		switch err {
+		case nil:
+			sol.Objectives = make([]float64, len(objs))
+			for i := range objs {
+				sol.Objectives[i] = sol.eval(&objs[i])
+			}
+
+			front = append(front, sol)
+		case ErrInfeasible:
+		default:
+			return nil, err
+		}
+
+		if !nextGridPoint(grid, opts.NumPoints) {
+			break
+		}
+	}
+
+	return nonDominated(front, objs, opts.Tol), nil
+}
+
+// optimizeBounded optimizes the objective subject to the model and the given
+// additional constraints, which are removed from the solver again afterwards.
+func (s *Session) optimizeBounded(
+	obj *Objective, bounds []*Constr,
+) (*Solution, error) {
+	defer func() {
+		for i := len(bounds) - 1; i >= 0; i-- {
+			s.delConstr(bounds[i])
+		}
+	}()
+
+	if s.obj == nil || !sameObjective(s.obj, obj) {
+		if err := s.setObjective(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, bound := range bounds {
+		if err := s.addConstr(bound); err != nil {
+			bounds = bounds[:i]
+			return nil, err
+		}
+	}
+
+	return s.optimize()
+}
+
+// nextGridPoint advances the indices of the grid to the next combination and
+// returns false once all combinations have been visited.
+func nextGridPoint(grid []int, numPoints int) bool {
+	for i := range grid {
+		grid[i]++
+		if grid[i] < numPoints {
+			return true
+		}
+
+		grid[i] = 0
+	}
+
+	return false
+}
+
+// nonDominated returns the solutions that are not dominated by any other
+// solution. Of solutions with equal objective vectors only the first is kept.
+func nonDominated(sols []*Solution, objs []Objective, tol float64) []*Solution {
+	var front []*Solution
+	for i, sol := range sols {
+		dominated := false
+		for j, other := range sols {
+			if i == j {
+				continue
+			}
+
+			if dominates(other, sol, objs, tol) ||
+				(j < i && equalObjectives(other, sol, tol)) {
+				dominated = true
+				break
+			}
+		}
+
+		if !dominated {
+			front = append(front, sol)
+		}
+	}
+
+	return front
+}
+
+// dominates returns true if a is at least as good as b in every objective and
+// strictly better in at least one.
+func dominates(a, b *Solution, objs []Objective, tol float64) bool {
+	better := false
+	for i, obj := range objs {
+		diff := (a.Objectives[i] - b.Objectives[i]) * float64(obj.sense)
+		if diff > tol {
+			return false
+		}
+
+		if diff < -tol {
+			better = true
+		}
+	}
+
+	return better
+}
+
+// equalObjectives returns true if both solutions have the same objective
+// vector within the tolerance.
+func equalObjectives(a, b *Solution, tol float64) bool {
+	for i := range a.Objectives {
+		if math.Abs(a.Objectives[i]-b.Objectives[i]) > tol {
+			return false
+		}
+	}
+
+	return true
+}
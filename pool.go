@@ -0,0 +1,158 @@
+package goop
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/mit-drl/goop/solvers"
+)
+
+// PoolOptions holds the options used to collect multiple solutions
+type PoolOptions struct {
+	// MinDistance is the minimum number of Binary variables in which every
+	// two returned solutions differ. It defaults to 1.
+	MinDistance int
+
+	// RelGap and AbsGap limit how much worse than the best solution the
+	// objective of a returned solution may be, relative to the best objective
+	// or in absolute terms. If both are set, the larger limit applies. If
+	// neither is set, the objective is not limited.
+	RelGap float64
+	AbsGap float64
+}
+
+// OptimizeN optimizes the model and returns up to k distinct feasible
+// solutions ordered from best to worst objective. If the solver maintains a
+// native solution pool and MinDistance is at most 1, the pool is used.
+// Otherwise, the model is solved repeatedly and after every solve a no-good
+// cut is added which excludes all solutions whose Binary variables are within
+// MinDistance of the ones found, so the model must have Binary variables.
+// Fewer than k solutions are returned if no further solutions exist. As with
+// Optimize, the solver is owned by the caller.
+func (m *Model) OptimizeN(
+	solver solvers.Solver, k int, opts PoolOptions,
+) ([]*Solution, error) {
+	if len(m.objs) > 0 {
+		return nil, errors.New(
+			"cannot collect multiple solutions of a hierarchical model",
+		)
+	}
+
+	if opts.MinDistance <= 0 {
+		opts.MinDistance = 1
+	}
+
+	s := m.Attach(solver)
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+
+	var sols []*Solution
+	var err error
+	if opts.MinDistance == 1 && solver.SupportsPool() {
+		sols, err = s.optimizePool(k, opts)
+	} else {
+		sols, err = s.optimizeNoGood(k, opts)
+	}
+
+	sort.SliceStable(sols, func(i, j int) bool {
+		return s.sense().worse(sols[j].Objective, sols[i].Objective)
+	})
+
+	return sols, err
+}
+
+// optimizePool collects the solutions from the solver's native pool
+func (s *Session) optimizePool(k int, opts PoolOptions) ([]*Solution, error) {
+	// The pool is not limited by the solver since the gap is the larger of a
+	// relative and an absolute gap, so it is checked below instead
+	s.solver.SetPoolSize(k, 0)
+	defer s.solver.ResetPool()
+	best, err := s.optimize()
+	if err != nil {
+		return nil, err
+	}
+
+	sols := []*Solution{best}
+	for i := 1; i < s.solver.GetPoolCount() && len(sols) < k; i++ {
+		sol := s.newSolution(s.solver.GetPoolSolution(i))
+		if sol.Status == StatusNotSolved {
+			return sols, sol.err()
+		}
+
+		if opts.withinGap(best.Objective, sol.Objective, s.sense()) {
+			sols = append(sols, sol)
+		}
+	}
+
+	return sols, nil
+}
+
+// optimizeNoGood collects solutions by excluding every solution found using
+// a no-good cut on the Binary variables of the model before solving again.
+func (s *Session) optimizeNoGood(k int, opts PoolOptions) ([]*Solution, error) {
+	var binaries []*Var
+	for _, v := range s.model.vars {
+		if v.Type() == Binary {
+			binaries = append(binaries, v)
+		}
+	}
+
+	if len(binaries) == 0 && k > 1 {
+		return nil, errors.New(
+			"no-good cuts require Binary variables in the model",
+		)
+	}
+
+	var sols []*Solution
+	for len(sols) < k {
+		sol, err := s.optimize()
+		if err == ErrInfeasible && len(sols) > 0 {
+			break
+		} else if err != nil {
+			return sols, err
+		}
+
+		if len(sols) > 0 &&
+			!opts.withinGap(sols[0].Objective, sol.Objective, s.sense()) {
+			break
+		}
+
+		sols = append(sols, sol)
+		if len(sols) < k {
+			cut := noGoodCut(sol, binaries, opts.MinDistance)
+			if err := s.addConstr(cut); err != nil {
+				return sols, err
+			}
+		}
+	}
+
+	return sols, nil
+}
+
+// noGoodCut returns the constraint that the Binary variables of any further
+// solution differ from their values in the solution in at least dist places
+func noGoodCut(sol *Solution, binaries []*Var, dist int) *Constr {
+	diff := NewExpr(0)
+	for _, v := range binaries {
+		if sol.Value(v) > 0.5 {
+			diff.Plus(Sum(One, v.Mult(-1)))
+		} else {
+			diff.Plus(v)
+		}
+	}
+
+	return diff.GreaterEq(K(float64(dist)))
+}
+
+// withinGap returns true if the objective val is within the allowed gap of
+// the best objective
+func (opts PoolOptions) withinGap(best, val float64, sense ObjSense) bool {
+	if opts.RelGap <= 0 && opts.AbsGap <= 0 {
+		return true
+	}
+
+	limit := math.Max(opts.RelGap*math.Abs(best), opts.AbsGap)
+	return !sense.worse(val, best+limit*float64(sense))
+}
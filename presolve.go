@@ -0,0 +1,471 @@
+package goop
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// presolveTol is the tolerance used when checking rows and bounds
+	presolveTol float64 = 1e-9
+
+	// maxPresolvePasses limits the number of passes over the rows, since
+	// bound tightening may converge slowly
+	maxPresolvePasses = 20
+)
+
+// SetPresolve turns presolve on or off. Presolve is on by default and
+// simplifies the model before it is sent to the solver by Optimize and
+// OptimizeContext: empty rows and columns are removed, fixed variables are
+// substituted, rows with a single variable are turned into bounds, bounds are
+// tightened using the activities of the rows, and trivially infeasible
+// constraints are detected. The values of removed variables are restored in
+// the solution. Sessions created with Attach are never presolved.
+func (m *Model) SetPresolve(on bool) {
+	m.noPresolve = !on
+}
+
+// psRow is a linear constraint lo <= sum(coeffs * vars) <= hi
+type psRow struct {
+	vars   []uint64
+	coeffs []float64
+	lo, hi float64
+
+	// constr is the index of the constraint in the model the row came from
+	constr int
+}
+
+// presolver holds the state of presolving a model
+type presolver struct {
+	model *Model
+
+	// vars holds copies of the variables of the model whose bounds are
+	// tightened, and protected holds variables that must be kept in the model
+	// even if they are fixed. The bounds of the results of general
+	// constraints are never changed, since a bound that is tighter than its
+	// expressions imply requires the big-M formulation.
+	vars      map[uint64]*Var
+	protected map[uint64]bool
+	results   map[uint64]bool
+	rows      []*psRow
+
+	// fixed holds the values of the variables removed from the model
+	fixed map[uint64]float64
+
+	infeasible bool
+}
+
+// presolve returns a presolved copy of the model and the values of the
+// variables that were removed from it. The returned model is nil if the model
+// was found to be infeasible. The model must be valid.
+func (m *Model) presolve() (*Model, map[uint64]float64) {
+	p := &presolver{
+		model:     m,
+		vars:      make(map[uint64]*Var, len(m.vars)),
+		protected: make(map[uint64]bool),
+		results:   make(map[uint64]bool),
+		fixed:     make(map[uint64]float64),
+	}
+
+	for _, v := range m.vars {
+		copied := *v
+		p.vars[v.ID()] = &copied
+
+		// The bounds of semi-continuous variables do not bound their values
+		// and the bounds of integer variables are rounded, so that removed
+		// variables take integer values
+		if v.isSemi() {
+			p.protected[v.ID()] = true
+		} else if v.Type() != Continuous {
+			p.tighten(&copied, v.Lower(), v.Upper())
+		}
+	}
+
+	for _, gc := range m.genConstrs {
+		p.protected[gc.result.ID()] = true
+		p.results[gc.result.ID()] = true
+		for _, e := range gc.exprs {
+			for _, id := range e.Vars() {
+				p.protected[id] = true
+			}
+		}
+	}
+
+	for i, constr := range m.constrs {
+		p.rows = append(p.rows, newPSRow(constr, i))
+	}
+
+	for pass := 0; pass < maxPresolvePasses && !p.infeasible; pass++ {
+		if !p.pass() {
+			break
+		}
+	}
+
+	if p.infeasible {
+		return nil, nil
+	}
+
+	reduced := p.reducedModel()
+	log.WithFields(log.Fields{
+		"removed_vars":    len(m.vars) - len(reduced.vars),
+		"removed_constrs": len(m.constrs) - len(reduced.constrs),
+	}).Info("Presolve finished")
+
+	return reduced, p.fixed
+}
+
+// newPSRow returns the row of the constraint with duplicate variables merged
+// and constants moved into its bounds
+func newPSRow(constr *Constr, index int) *psRow {
+	row := &psRow{constr: index}
+	cols := make(map[uint64]int)
+	add := func(e Expr, sign float64) {
+		coeffs := e.Coeffs()
+		for i, id := range e.Vars() {
+			col, ok := cols[id]
+			if !ok {
+				col = len(row.vars)
+				cols[id] = col
+				row.vars = append(row.vars, id)
+				row.coeffs = append(row.coeffs, 0)
+			}
+
+			row.coeffs[col] += sign * coeffs[i]
+		}
+	}
+
+	add(constr.lhs, 1)
+	add(constr.rhs, -1)
+	row.lo, row.hi = constr.rowBounds()
+	return row
+}
+
+// pass presolves every row once and returns true if anything changed
+func (p *presolver) pass() bool {
+	changed := false
+	rows := p.rows[:0]
+	for _, row := range p.rows {
+		keep, rowChanged := p.presolveRow(row)
+		if p.infeasible {
+			log.WithField(
+				"constr", row.constr,
+			).Info("Presolve found infeasible constraint")
+			return false
+		}
+
+		changed = changed || rowChanged || !keep
+		if keep {
+			rows = append(rows, row)
+		}
+	}
+
+	p.rows = rows
+	return changed
+}
+
+// presolveRow simplifies a single row and tightens the bounds of its
+// variables. It returns whether the row must be kept and whether anything
+// changed.
+func (p *presolver) presolveRow(row *psRow) (keep bool, changed bool) {
+	// Substitute fixed variables and drop zero coefficients
+	n := 0
+	for i, id := range row.vars {
+		v := p.vars[id]
+		switch {
+		case row.coeffs[i] == 0:
+			changed = true
+		case v.Lower() == v.Upper() && !v.isSemi():
+			row.lo -= row.coeffs[i] * v.Lower()
+			row.hi -= row.coeffs[i] * v.Lower()
+			changed = true
+		default:
+			row.vars[n], row.coeffs[n] = id, row.coeffs[i]
+			n++
+		}
+	}
+
+	row.vars, row.coeffs = row.vars[:n], row.coeffs[:n]
+
+	switch {
+	case len(row.vars) == 0:
+		p.infeasible = row.lo > presolveTol || row.hi < -presolveTol
+		return false, true
+	case len(row.vars) == 1 && !p.vars[row.vars[0]].isSemi() &&
+		!p.results[row.vars[0]]:
+		v, a := p.vars[row.vars[0]], row.coeffs[0]
+		lo, hi := row.lo/a, row.hi/a
+		if a < 0 {
+			lo, hi = hi, lo
+		}
+
+		p.tighten(v, lo, hi)
+		return false, true
+	}
+
+	minAct, maxAct := p.activity(row)
+	if minAct.value() > row.hi+presolveTol ||
+		maxAct.value() < row.lo-presolveTol {
+		p.infeasible = true
+		return false, true
+	}
+
+	// The row can never be violated given the bounds of its variables
+	if minAct.value() >= row.lo && maxAct.value() <= row.hi {
+		return false, true
+	}
+
+	for i, id := range row.vars {
+		v, a := p.vars[id], row.coeffs[i]
+		if v.isSemi() || p.results[id] {
+			continue
+		}
+
+		lo, hi := p.bounds(v)
+		minRes := minAct.without(a * lo)
+		maxRes := maxAct.without(a * hi)
+		if a < 0 {
+			minRes, maxRes = minAct.without(a*hi), maxAct.without(a*lo)
+		}
+
+		// a * x <= hi - minRes and a * x >= lo - maxRes
+		newLo, newHi := (row.lo-maxRes)/a, (row.hi-minRes)/a
+		if a < 0 {
+			newLo, newHi = newHi, newLo
+		}
+
+		if p.tighten(v, newLo, newHi) {
+			changed = true
+		}
+
+		if p.infeasible {
+			return false, true
+		}
+	}
+
+	return true, changed
+}
+
+// activity is the sum of a finite part and a number of infinite terms
+type activity struct {
+	finite float64
+	numInf int
+	sign   float64
+}
+
+func (a activity) value() float64 {
+	if a.numInf > 0 {
+		return math.Inf(int(a.sign))
+	}
+
+	return a.finite
+}
+
+// without returns the activity without the given term, or an infinite value
+// if other infinite terms remain
+func (a activity) without(term float64) float64 {
+	if math.IsInf(term, 0) {
+		if a.numInf > 1 {
+			return math.Inf(int(a.sign))
+		}
+
+		return a.finite
+	}
+
+	if a.numInf > 0 {
+		return math.Inf(int(a.sign))
+	}
+
+	return a.finite - term
+}
+
+// activity returns the smallest and largest value the row can take
+func (p *presolver) activity(row *psRow) (activity, activity) {
+	minAct, maxAct := activity{sign: -1}, activity{sign: 1}
+	add := func(act *activity, term float64) {
+		if math.IsInf(term, 0) {
+			act.numInf++
+		} else {
+			act.finite += term
+		}
+	}
+
+	for i, id := range row.vars {
+		lo, hi := p.bounds(p.vars[id])
+		a := row.coeffs[i]
+		if a > 0 {
+			add(&minAct, a*lo)
+			add(&maxAct, a*hi)
+		} else {
+			add(&minAct, a*hi)
+			add(&maxAct, a*lo)
+		}
+	}
+
+	return minAct, maxAct
+}
+
+// bounds returns the bounds of the values the variable can take, which for
+// semi-continuous variables include zero
+func (p *presolver) bounds(v *Var) (float64, float64) {
+	if v.isSemi() {
+		return math.Min(v.Lower(), 0), math.Max(v.Upper(), 0)
+	}
+
+	return v.Lower(), v.Upper()
+}
+
+// tighten sets the bounds of the variable to the given bounds where they are
+// tighter and returns true if a bound changed. Bounds of integer variables are
+// rounded.
+func (p *presolver) tighten(v *Var, lo, hi float64) bool {
+	if v.Type() != Continuous {
+		lo, hi = math.Ceil(lo-1e-6), math.Floor(hi+1e-6)
+	}
+
+	changed := false
+	if lo > v.Lower()+1e-7*math.Max(1, math.Abs(v.Lower())) {
+		v.lower = lo
+		changed = true
+	}
+
+	if hi < v.Upper()-1e-7*math.Max(1, math.Abs(v.Upper())) {
+		v.upper = hi
+		changed = true
+	}
+
+	if v.Lower() > v.Upper()+presolveTol {
+		p.infeasible = true
+	} else if v.Lower() > v.Upper() {
+		v.upper = v.lower
+	}
+
+	return changed
+}
+
+// reducedModel returns a copy of the model without the removed rows and with
+// fixed and unused variables substituted by their values
+func (p *presolver) reducedModel() *Model {
+	m := p.model
+	used := make(map[uint64]bool)
+	for _, row := range p.rows {
+		for _, id := range row.vars {
+			used[id] = true
+		}
+	}
+
+	inObjs := make(map[uint64]bool)
+	for _, obj := range m.objs {
+		for _, id := range obj.Vars() {
+			inObjs[id] = true
+		}
+	}
+
+	reduced := &Model{
+		byID:       make(map[uint64]*Var),
+		showLog:    m.showLog,
+		timeLimit:  m.timeLimit,
+		onProgress: m.onProgress,
+		params:     m.params,
+	}
+
+	for _, orig := range m.vars {
+		v := p.vars[orig.ID()]
+		if val, ok := p.removedValue(v, used[v.ID()] || inObjs[v.ID()]); ok {
+			p.fixed[v.ID()] = val
+			continue
+		}
+
+		reduced.vars = append(reduced.vars, v)
+		reduced.byID[v.ID()] = v
+	}
+
+	for _, row := range p.rows {
+		e := &LinearExpr{vars: row.vars, coeffs: row.coeffs}
+		var constr *Constr
+		switch {
+		case row.lo == row.hi:
+			constr = e.Eq(K(row.hi))
+		case math.IsInf(row.lo, -1):
+			constr = e.LessEq(K(row.hi))
+		case math.IsInf(row.hi, 1):
+			constr = e.GreaterEq(K(row.lo))
+		default:
+			constr = Between(row.lo, e, row.hi)
+		}
+
+		reduced.constrs = append(reduced.constrs, constr)
+	}
+
+	for _, gc := range m.genConstrs {
+		reduced.appendGenConstr(&genConstr{
+			gtype:    gc.gtype,
+			result:   reduced.byID[gc.result.ID()],
+			exprs:    gc.exprs,
+			minLower: gc.minLower,
+		})
+	}
+
+	if m.obj != nil {
+		reduced.obj = NewObjective(p.substitute(m.obj), m.obj.sense)
+	}
+
+	for _, obj := range m.objs {
+		reduced.objs = append(reduced.objs, &prioritizedObjective{
+			NewObjective(p.substitute(obj), obj.sense),
+			obj.priority, obj.relTol, obj.absTol,
+		})
+	}
+
+	return reduced
+}
+
+// removedValue returns the value of the variable and true if it can be
+// removed from the model
+func (p *presolver) removedValue(v *Var, used bool) (float64, bool) {
+	if p.protected[v.ID()] {
+		return 0, false
+	}
+
+	if v.Lower() == v.Upper() {
+		return v.Lower(), true
+	}
+
+	if used {
+		return 0, false
+	}
+
+	// Unused variables only appear in the objective, if at all, so they take
+	// the bound preferred by the objective
+	coeff := 0.0
+	if p.model.obj != nil {
+		coeff = coeffOf(p.model.obj, v.ID()) * float64(p.model.obj.sense)
+	}
+
+	val := math.Max(v.Lower(), math.Min(v.Upper(), 0))
+	if coeff > 0 {
+		val = v.Lower()
+	} else if coeff < 0 {
+		val = v.Upper()
+	}
+
+	return val, !math.IsInf(val, 0)
+}
+
+// substitute returns a copy of the expression with the removed variables
+// replaced by their values
+func (p *presolver) substitute(e Expr) Expr {
+	newExpr := &LinearExpr{constant: e.Constant()}
+	coeffs := e.Coeffs()
+	for i, id := range e.Vars() {
+		if val, ok := p.fixed[id]; ok {
+			newExpr.constant += coeffs[i] * val
+			continue
+		}
+
+		newExpr.vars = append(newExpr.vars, id)
+		newExpr.coeffs = append(newExpr.coeffs, coeffs[i])
+	}
+
+	return newExpr
+}
@@ -0,0 +1,230 @@
+package goop
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PrintOptions control how a model is printed
+type PrintOptions struct {
+	// MaxTerms is the number of terms printed per expression before the
+	// remaining terms are elided. Zero prints all terms.
+	MaxTerms int
+
+	// MaxConstrs is the number of constraints printed before the remaining
+	// constraints are elided. Zero prints all constraints.
+	MaxConstrs int
+}
+
+// SetPrintOptions sets the options used by String to print the model
+func (m *Model) SetPrintOptions(opts PrintOptions) {
+	m.printOpts = opts
+}
+
+// printer formats expressions, constraints and objectives
+type printer struct {
+	opts  PrintOptions
+	names map[uint64]string
+}
+
+// defaultVarName returns the name of a variable without a name
+func defaultVarName(id uint64) string {
+	return "x" + strconv.FormatUint(id, 10)
+}
+
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+func (p printer) varName(id uint64) string {
+	if name := p.names[id]; name != "" {
+		return name
+	}
+
+	return defaultVarName(id)
+}
+
+// expr formats an expression as for example 2 x + y - 3 z + 4
+func (p printer) expr(e Expr) string {
+	var b bytes.Buffer
+	coeffs := e.Coeffs()
+	for i, id := range e.Vars() {
+		if p.opts.MaxTerms > 0 && i == p.opts.MaxTerms {
+			fmt.Fprintf(&b, " + ... (%d more terms)", e.NumVars()-i)
+			break
+		}
+
+		coeff := coeffs[i]
+		switch {
+		case i == 0 && coeff < 0:
+			b.WriteString("-")
+		case i > 0 && coeff < 0:
+			b.WriteString(" - ")
+		case i > 0:
+			b.WriteString(" + ")
+		}
+
+		if coeff < 0 {
+			coeff = -coeff
+		}
+
+		if coeff != 1 {
+			b.WriteString(formatFloat(coeff) + " ")
+		}
+
+		b.WriteString(p.varName(id))
+	}
+
+	switch constant := e.Constant(); {
+	case e.NumVars() == 0:
+		b.WriteString(formatFloat(constant))
+	case constant > 0:
+		b.WriteString(" + " + formatFloat(constant))
+	case constant < 0:
+		b.WriteString(" - " + formatFloat(-constant))
+	}
+
+	return b.String()
+}
+
+// constr formats a constraint as for example x + y <= 4
+func (p printer) constr(c *Constr) string {
+	switch c.sense {
+	case SenseRange:
+		return fmt.Sprintf(
+			"%s <= %s <= %s",
+			formatFloat(c.lower), p.expr(c.lhs), p.expr(c.rhs),
+		)
+	case SenseEqual:
+		return p.expr(c.lhs) + " = " + p.expr(c.rhs)
+	default:
+		return fmt.Sprintf(
+			"%s %c= %s", p.expr(c.lhs), byte(c.sense), p.expr(c.rhs),
+		)
+	}
+}
+
+// objective formats an objective as for example maximize x + y
+func (p printer) objective(o *Objective) string {
+	if o.sense == SenseMaximize {
+		return "maximize " + p.expr(o)
+	}
+
+	return "minimize " + p.expr(o)
+}
+
+// String returns the constant as a number
+func (c K) String() string {
+	return formatFloat(float64(c))
+}
+
+// String returns the expression in algebraic form. Expressions do not know
+// the names of their variables, so variables are referred to as x followed by
+// their ID. IDs are assigned from a process-wide counter and may differ
+// between runs; use Model.ExprString to print the names of the variables.
+func (e *LinearExpr) String() string {
+	return printer{}.expr(e)
+}
+
+// String returns the constraint in algebraic form. Like LinearExpr.String,
+// variables are referred to as x followed by their ID; use Model.ConstrString
+// to print the names of the variables.
+func (c *Constr) String() string {
+	return printer{}.constr(c)
+}
+
+// String returns the objective in algebraic form. Like LinearExpr.String,
+// variables are referred to as x followed by their ID; use
+// Model.ObjectiveString to print the names of the variables.
+func (o *Objective) String() string {
+	return printer{}.objective(o)
+}
+
+// ExprString returns the expression in algebraic form, referring to variables
+// by their names in the model. Unnamed variables are referred to as x
+// followed by their ID and long expressions are elided according to the print
+// options of the model.
+func (m *Model) ExprString(e Expr) string {
+	return m.printer().expr(e)
+}
+
+// ConstrString returns the constraint in algebraic form, referring to
+// variables by their names in the model
+func (m *Model) ConstrString(c *Constr) string {
+	return m.printer().constr(c)
+}
+
+// ObjectiveString returns the objective in algebraic form, referring to
+// variables by their names in the model
+func (m *Model) ObjectiveString(o *Objective) string {
+	return m.printer().objective(o)
+}
+
+// printer returns a printer that uses the print options of the model and the
+// names of its variables
+func (m *Model) printer() printer {
+	p := printer{opts: m.printOpts, names: make(map[uint64]string)}
+	for _, v := range m.vars {
+		if v.name != "" {
+			p.names[v.ID()] = v.name
+		}
+	}
+
+	return p
+}
+
+// String returns the model in algebraic form on a single line, for example
+// maximize x + y  s.t.  c0: x + 2 y <= 4. Variables are referred to by their
+// names, or as x followed by their ID if unnamed, and long expressions or
+// constraint lists are elided according to the print options of the model.
+func (m *Model) String() string {
+	p := m.printer()
+
+	var parts []string
+	for _, obj := range m.stages() {
+		parts = append(parts, fmt.Sprintf(
+			"%s (priority %d)", p.objective(obj.Objective), obj.priority,
+		))
+	}
+
+	if len(m.objs) == 0 && m.obj != nil {
+		parts = append(parts, p.objective(m.obj))
+	}
+
+	var constrs []string
+	for i, c := range m.constrs {
+		if p.opts.MaxConstrs > 0 && i == p.opts.MaxConstrs {
+			constrs = append(constrs, fmt.Sprintf(
+				"... (%d more constraints)", len(m.constrs)-i,
+			))
+			break
+		}
+
+		constrs = append(constrs, fmt.Sprintf("c%d: %s", i, p.constr(c)))
+	}
+
+	for i, gc := range m.genConstrs {
+		fn := "max"
+		if gc.gtype == genMin {
+			fn = "min"
+		}
+
+		exprs := make([]string, len(gc.exprs))
+		for j, e := range gc.exprs {
+			exprs[j] = p.expr(e)
+		}
+
+		constrs = append(constrs, fmt.Sprintf(
+			"g%d: %s = %s(%s)",
+			i, p.varName(gc.result.ID()), fn, strings.Join(exprs, ", "),
+		))
+	}
+
+	if len(constrs) > 0 {
+		parts = append(parts, "s.t.", strings.Join(constrs, "  "))
+	}
+
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,72 @@
+package goop_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestModelString(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 10, goop.Continuous)
+	y := m.AddVar(0, 10, goop.Continuous)
+	z := m.AddVar(0, 10, goop.Continuous)
+	m.SetName(x, "x")
+	m.SetName(y, "y")
+	m.SetName(z, "z")
+	m.AddConstr(goop.Sum(x, y.Mult(2), z.Mult(3)).LessEq(goop.K(4)))
+	m.AddConstr(goop.Sum(x, y.Mult(-1)).GreaterEq(goop.K(-1)))
+	m.SetObjective(goop.Sum(x, y, z.Mult(2)), goop.SenseMaximize)
+
+	expected := "maximize x + y + 2 z  s.t.  c0: x + 2 y + 3 z <= 4  " +
+		"c1: x - y >= -1"
+	if s := fmt.Sprintf("%v", m); s != expected {
+		t.Errorf("String mismatch: %q != %q", s, expected)
+	}
+
+	m.SetPrintOptions(goop.PrintOptions{MaxTerms: 2, MaxConstrs: 1})
+	expected = "maximize x + y + ... (1 more terms)  s.t.  " +
+		"c0: x + 2 y + ... (1 more terms) <= 4  ... (1 more constraints)"
+	if s := m.String(); s != expected {
+		t.Errorf("String mismatch: %q != %q", s, expected)
+	}
+}
+
+func TestExprString(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+	m.SetName(x, "x")
+
+	// Expressions do not know the names of their variables
+	expr := goop.Sum(x.Mult(-1.5), goop.K(2))
+	expected := fmt.Sprintf("-1.5 x%d + 2", x.ID())
+	if s := fmt.Sprintf("%v", expr); s != expected {
+		t.Errorf("String mismatch: %q != %q", s, expected)
+	}
+
+	expected = "-1.5 x + 2"
+	if s := m.ExprString(expr); s != expected {
+		t.Errorf("ExprString mismatch: %q != %q", s, expected)
+	}
+
+	constr := goop.Sum(x, goop.K(1)).LessEq(goop.K(2))
+	expected = "x + 1 <= 2"
+	if s := m.ConstrString(constr); s != expected {
+		t.Errorf("ConstrString mismatch: %q != %q", s, expected)
+	}
+
+	obj := goop.NewObjective(x.Mult(2), goop.SenseMinimize)
+	expected = "minimize 2 x"
+	if s := m.ObjectiveString(obj); s != expected {
+		t.Errorf("ObjectiveString mismatch: %q != %q", s, expected)
+	}
+
+	if s := x.String(); s != "x" {
+		t.Errorf("String mismatch: %q != %q", s, "x")
+	}
+
+	if s := goop.K(3).String(); s != "3" {
+		t.Errorf("String mismatch: %q != %q", s, "3")
+	}
+}
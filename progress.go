@@ -0,0 +1,97 @@
+package goop
+
+import (
+	"math"
+	"time"
+
+	"github.com/mit-drl/goop/solvers"
+)
+
+// ProgressEvent describes the state of a solve in progress. Values that are
+// unknown, such as the incumbent before the first feasible solution is found
+// or a bound the solver does not report, are NaN. LPSolve does not report a
+// bound, so BestBound and Gap are always NaN with that solver.
+type ProgressEvent struct {
+	// Incumbent is the objective of the best solution found so far
+	Incumbent float64
+
+	// BestBound is the best bound on the optimal objective
+	BestBound float64
+
+	// Gap is the relative gap between the incumbent and the best bound,
+	// computed as |Incumbent - BestBound| / |Incumbent|. As in Gurobi, the gap
+	// is zero if both are zero and infinite if only the incumbent is zero.
+	Gap float64
+
+	// Nodes is the number of branch and bound nodes explored so far
+	Nodes int
+
+	// Elapsed is the time since the solve started
+	Elapsed time.Duration
+}
+
+// OnProgress registers a function that is called periodically by the solver
+// while the model is optimized. If the function returns false, the solver
+// stops as soon as possible and the best solution found so far is returned
+// with the status StatusSuboptimal, or StatusNotSolved if there is none.
+// Passing nil removes the function.
+func (m *Model) OnProgress(f func(ProgressEvent) bool) {
+	m.onProgress = f
+}
+
+// progressHandler implements the ProgressCallback director of the solvers
+// package and forwards the progress to the function registered on the model
+type progressHandler struct {
+	f   func(ProgressEvent) bool
+	inf float64
+}
+
+func (h *progressHandler) OnProgress(
+	incumbent, bound, nodes, elapsed float64,
+) bool {
+	e := ProgressEvent{
+		Incumbent: fromSolverInf(incumbent, h.inf),
+		BestBound: fromSolverInf(bound, h.inf),
+		Nodes:     int(nodes),
+		Elapsed:   time.Duration(elapsed * float64(time.Second)),
+	}
+
+	e.Gap = relGap(e.Incumbent, e.BestBound)
+
+	return h.f(e)
+}
+
+// relGap returns the relative gap between an incumbent and a bound. It is NaN
+// if either is unknown or infinite.
+func relGap(incumbent, bound float64) float64 {
+	switch {
+	case math.IsNaN(incumbent) || math.IsNaN(bound):
+		return math.NaN()
+	case math.IsInf(incumbent, 0) || math.IsInf(bound, 0):
+		return math.NaN()
+	case math.Abs(incumbent) < 1e-10 && math.Abs(bound) < 1e-10:
+		return 0
+	case math.Abs(incumbent) < 1e-10:
+		return math.Inf(1)
+	}
+
+	return math.Abs(incumbent-bound) / math.Abs(incumbent)
+}
+
+// watchProgress installs the progress function of the model in the solver
+// and returns a function that removes it again once the solve is done
+func (s *Session) watchProgress() func() {
+	if s.model.onProgress == nil {
+		return func() {}
+	}
+
+	cb := solvers.NewDirectorProgressCallback(
+		&progressHandler{s.model.onProgress, s.inf},
+	)
+	s.solver.SetProgressCallback(cb)
+
+	return func() {
+		s.solver.ClearProgressCallback()
+		solvers.DeleteDirectorProgressCallback(cb)
+	}
+}
@@ -0,0 +1,726 @@
+package goop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mit-drl/goop/solvers"
+	log "github.com/sirupsen/logrus"
+)
+
+// linearization represents how much of a general constraint has been sent to
+// the solver.
+type linearization int
+
+// General constraints are first sent in their epigraph form, which may later
+// be extended with big-M constraints if the epigraph form is no longer exact.
+const (
+	linearNone linearization = iota
+	linearEpigraph
+	linearBigM
+)
+
+// Session is a persistent connection between a model and a solver. Unlike
+// Model.Optimize, which loads the whole model into the solver every time, a
+// session only sends the changes made to the model since the last call to
+// Optimize: new and removed variables and constraints, changed variable
+// bounds and types, and a changed objective. This allows the solver to keep
+// its previous basis and warm start subsequent solves. The first call sends
+// the whole model to the solver at once using LoadModel. The solver is not
+// deleted by the session and must be deleted using solvers.DeleteSolver, or
+// closed if it is a Solver, once the session is no longer needed.
+type Session struct {
+	model  *Model
+	solver solvers.Solver
+	inf    float64
+
+	// cols maps the ID of every variable sent to the solver to its column,
+	// and colIDs holds the ID of the variable in every column. Removing a
+	// column shifts the columns after it, just like in the solver.
+	cols   map[uint64]int
+	colIDs []uint64
+	numAux uint64
+
+	// rows maps every constraint sent to the solver to its row, and
+	// rowConstrs holds the constraint in every row
+	rows       map[*Constr]int
+	rowConstrs []*Constr
+
+	// vars holds copies of the model's variables as they were last sent to
+	// the solver. relaxed holds the indicators of semi-continuous variables
+	// that were reformulated since the solver does not support them natively.
+	vars    map[uint64]Var
+	relaxed map[uint64]*semiIndicator
+	constrs map[*Constr]bool
+	levels  map[*genConstr]linearization
+	links   map[*genConstr]*bigMLink
+	obj     *Objective
+
+	// pending holds the variables and constraints to be sent on the next sync.
+	// Until the model is loaded, they are sent with a single LoadModel call.
+	pendingVars    []*Var
+	pendingConstrs []*Constr
+	loaded         bool
+}
+
+// bigMLink holds the binary variables and big-M constraints that link the
+// result of a general constraint to one of its expressions, and the big-M
+// constants the constraints were built with
+type bigMLink struct {
+	bigMs    []float64
+	binaries []*Var
+	constrs  []*Constr
+}
+
+// semiIndicator holds the binary indicator variable and constraints used to
+// reformulate a semi-continuous variable
+type semiIndicator struct {
+	indicator *Var
+	constrs   []*Constr
+}
+
+// Attach returns a new session that incrementally sends the model to the
+// given solver.
+func (m *Model) Attach(solver solvers.Solver) *Session {
+	return &Session{
+		model:   m,
+		solver:  solver,
+		cols:    make(map[uint64]int),
+		rows:    make(map[*Constr]int),
+		vars:    make(map[uint64]Var),
+		relaxed: make(map[uint64]*semiIndicator),
+		constrs: make(map[*Constr]bool),
+		levels:  make(map[*genConstr]linearization),
+		links:   make(map[*genConstr]*bigMLink),
+	}
+}
+
+// Optimize sends all changes made to the model since the last call to the
+// solver, optimizes the model and returns the solution. Errors are returned
+// in the same way as Model.Optimize. If objectives were added using
+// AddObjective, they are optimized one after the other in order of priority.
+func (s *Session) Optimize() (*Solution, error) {
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+
+	if len(s.model.objs) > 0 {
+		return s.optimizeStages()
+	}
+
+	return s.optimize()
+}
+
+// OptimizeContext is like Optimize but stops the solver when the context is
+// cancelled or its deadline passes. In that case the best solution found so
+// far, if any, is returned along with ctx.Err().
+func (s *Session) OptimizeContext(ctx context.Context) (*Solution, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := checkSolver(s.solver); err != nil {
+		return nil, err
+	}
+
+	// The solver is terminated from a separate goroutine since Optimize only
+	// returns once the solver has stopped
+	s.solver.ClearTerminate()
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			s.solver.Terminate()
+		case <-stop:
+		}
+	}()
+
+	sol, err := s.Optimize()
+	close(stop)
+	<-stopped
+	s.solver.ClearTerminate()
+
+	if ctx.Err() != nil {
+		return sol, ctx.Err()
+	}
+
+	return sol, err
+}
+
+// prepare sends all changes made to the model and its settings to the solver
+func (s *Session) prepare() error {
+	if err := checkSolver(s.solver); err != nil {
+		return err
+	}
+
+	// The infinity is only read once the solver is known not to be closed
+	s.inf = s.solver.Infinity()
+	m := s.model
+	if len(m.vars) == 0 {
+		return errors.New("no variables in model")
+	}
+
+	// The model is validated before every solve since any change, not only
+	// removing a variable, may leave it referring to unknown variables
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.sync(); err != nil {
+		return err
+	}
+
+	s.solver.ShowLog(m.showLog)
+
+	if m.timeLimit > 0 {
+		s.solver.SetTimeLimit(m.timeLimit.Seconds())
+	}
+
+	for param, value := range m.params {
+		if !s.solver.SupportsParam(int(param)) {
+			return fmt.Errorf("parameter %v is not supported by the solver", param)
+		}
+
+		s.solver.SetParam(int(param), value)
+	}
+
+	return nil
+}
+
+// optimize solves the model as it was last sent to the solver.
+func (s *Session) optimize() (*Solution, error) {
+	done := s.watchProgress()
+	mipSol := s.solver.Optimize()
+	done()
+
+	if mipSol.GetErrorCode() != 0 {
+		msg := fmt.Sprintf(
+			"[Code = %d] %s",
+			mipSol.GetErrorCode(),
+			mipSol.GetErrorMessage(),
+		)
+		solvers.DeleteMIPSolution(mipSol)
+		return nil, errors.New(msg)
+	}
+
+	sol := s.newSolution(mipSol)
+	return sol, sol.err()
+}
+
+// newSolution returns the solution of the model for a solution returned by
+// the solver. The solver's solution is deleted.
+func (s *Session) newSolution(mipSol solvers.MIPSolution) *Solution {
+	cols := make(map[uint64]int, len(s.vars))
+	for id := range s.vars {
+		cols[id] = s.cols[id]
+	}
+
+	sol := newSolution(mipSol, s.inf, s.sense(), cols)
+	sol.setVars(s.model.vars)
+	return sol
+}
+
+// sense returns the sense of the objective last sent to the solver
+func (s *Session) sense() ObjSense {
+	if s.obj == nil {
+		return SenseMinimize
+	}
+
+	return s.obj.sense
+}
+
+// optimizeStages optimizes the objectives of a hierarchical model in order of
+// priority. The constraints keeping earlier objectives near their optimal
+// values are removed from the solver again once all stages are solved, so
+// that they do not affect later calls to Optimize.
+func (s *Session) optimizeStages() (*Solution, error) {
+	var bounds []*Constr
+	defer func() {
+		for i := len(bounds) - 1; i >= 0; i-- {
+			s.delConstr(bounds[i])
+		}
+	}()
+
+	var sol *Solution
+	stages := s.model.stages()
+	for i, stage := range stages {
+		if s.obj == nil || !sameObjective(s.obj, stage.Objective) {
+			if err := s.setObjective(stage.Objective); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		sol, err = s.optimize()
+		if err != nil {
+			return sol, err
+		}
+
+		if i < len(stages)-1 {
+			bound := stage.bound(sol.Objective)
+			if err := s.addConstr(bound); err != nil {
+				return nil, err
+			}
+
+			bounds = append(bounds, bound)
+		}
+	}
+
+	sol.setStages(stages)
+	return sol, nil
+}
+
+// sync sends all changes made to the model since the last sync to the solver.
+// Removed rows and columns are deleted first, and new columns are always sent
+// before the constraints that use them.
+func (s *Session) sync() error {
+	m := s.model
+	s.removeStale()
+
+	for _, v := range m.vars {
+		if _, ok := s.vars[v.ID()]; ok {
+			if err := s.updateVar(v); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		s.vars[v.ID()] = *v
+		if !v.isSemi() || s.solver.SupportsVarType(byte(v.Type())) {
+			s.addVar(v)
+			continue
+		}
+
+		s.addVar(relaxSemi(v))
+		z, constrs, err := semiIndicatorConstrs(v, s.newAuxVar)
+		if err != nil {
+			return err
+		}
+
+		s.relaxed[v.ID()] = &semiIndicator{z, constrs}
+		s.pendingConstrs = append(s.pendingConstrs, constrs...)
+	}
+
+	for _, constr := range m.constrs {
+		if !s.constrs[constr] {
+			s.constrs[constr] = true
+			s.pendingConstrs = append(s.pendingConstrs, constr)
+		}
+	}
+
+	for _, gc := range m.genConstrs {
+		if s.levels[gc] < linearEpigraph {
+			s.pendingConstrs = append(s.pendingConstrs, gc.epigraph()...)
+			s.levels[gc] = linearEpigraph
+		}
+
+		if s.levels[gc] < linearBigM && !m.isEpigraphExact(gc) {
+			bigMs, err := m.bigMs(gc)
+			if err != nil {
+				return err
+			}
+
+			link := &bigMLink{
+				bigMs:    bigMs,
+				binaries: make([]*Var, len(gc.exprs)),
+			}
+			for i := range link.binaries {
+				link.binaries[i] = s.newAuxVar(0, 1, Binary)
+			}
+
+			link.constrs = gc.bigMConstrs(bigMs, link.binaries)
+			s.pendingConstrs = append(s.pendingConstrs, link.constrs...)
+			s.pendingConstrs = append(
+				s.pendingConstrs, selectOne(link.binaries),
+			)
+			s.links[gc] = link
+			s.levels[gc] = linearBigM
+		} else if s.levels[gc] == linearBigM {
+			if err := s.updateLink(gc); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// The objectives of hierarchical models are set while optimizing
+	if len(m.objs) == 0 && m.obj != nil &&
+		(s.obj == nil || !sameObjective(s.obj, m.obj)) {
+		if err := s.setObjective(m.obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateLink replaces the big-M constraints of the general constraint if the
+// bounds of its expressions or result changed since they were sent
+func (s *Session) updateLink(gc *genConstr) error {
+	bigMs, err := s.model.bigMs(gc)
+	if err != nil {
+		return err
+	}
+
+	link := s.links[gc]
+	same := true
+	for i := range bigMs {
+		same = same && bigMs[i] == link.bigMs[i]
+	}
+
+	if same {
+		return nil
+	}
+
+	for _, constr := range link.constrs {
+		s.delConstr(constr)
+	}
+
+	link.bigMs = bigMs
+	link.constrs = gc.bigMConstrs(bigMs, link.binaries)
+	s.pendingConstrs = append(s.pendingConstrs, link.constrs...)
+	return nil
+}
+
+// removeStale deletes the rows of constraints and the columns of variables
+// that were removed from the model since the last sync.
+func (s *Session) removeStale() {
+	live := make(map[*Constr]bool, len(s.model.constrs))
+	for _, constr := range s.model.constrs {
+		live[constr] = true
+	}
+
+	for constr := range s.constrs {
+		if !live[constr] {
+			s.delConstr(constr)
+			delete(s.constrs, constr)
+		}
+	}
+
+	for id := range s.vars {
+		if _, ok := s.model.byID[id]; ok {
+			continue
+		}
+
+		if semi, ok := s.relaxed[id]; ok {
+			for _, constr := range semi.constrs {
+				s.delConstr(constr)
+			}
+
+			s.delVar(semi.indicator.ID())
+			delete(s.relaxed, id)
+		}
+
+		s.delVar(id)
+		delete(s.vars, id)
+	}
+}
+
+// flush sends the pending variables and constraints to the solver. The
+// first flush loads the whole model, including its objective, at once.
+func (s *Session) flush() error {
+	if !s.loaded {
+		return s.load()
+	}
+
+	if len(s.pendingVars) > 0 {
+		lbs, ubs, types := s.colData(s.pendingVars)
+		s.solver.AddVars(len(s.pendingVars), &lbs[0], &ubs[0], types)
+		s.pendingVars = nil
+	}
+
+	if err := s.addConstrs(s.pendingConstrs); err != nil {
+		return err
+	}
+
+	s.pendingConstrs = nil
+	return nil
+}
+
+// load sends the pending variables and constraints and the objective of the
+// model to the empty solver in a single call
+func (s *Session) load() error {
+	rowPtr, cols, coeffs, lower, upper, err := s.rowData(s.pendingConstrs)
+	if err != nil {
+		return err
+	}
+
+	// The objectives of hierarchical models are set while optimizing
+	obj := NewObjective(NewExpr(0), SenseMinimize)
+	if len(s.model.objs) == 0 && s.model.obj != nil {
+		obj = s.model.obj
+	}
+
+	objCols, err := s.columns(obj)
+	if err != nil {
+		return err
+	}
+
+	lbs, ubs, types := s.colData(s.pendingVars)
+	s.solver.LoadModel(
+		len(s.pendingVars), &lbs[0], &ubs[0], types,
+		len(s.pendingConstrs), &rowPtr[0], getColsPtr(cols),
+		getFloatsPtr(coeffs), getFloatsPtr(lower), getFloatsPtr(upper),
+		obj.NumVars(), getCoeffsPtr(obj), getColsPtr(objCols),
+		obj.Constant(), int(obj.sense),
+	)
+
+	s.assignRows(s.pendingConstrs)
+	if obj == s.model.obj {
+		s.obj = NewObjective(scaleExpr(obj, 1), obj.sense)
+	}
+
+	s.pendingVars = nil
+	s.pendingConstrs = nil
+	s.loaded = true
+	return nil
+}
+
+// colData returns the bounds and types of the variables as sent to the
+// solver
+func (s *Session) colData(vars []*Var) ([]float64, []float64, string) {
+	lbs := make([]float64, len(vars))
+	ubs := make([]float64, len(vars))
+	types := make([]byte, len(vars))
+	for i, v := range vars {
+		lbs[i] = toSolverInf(v.Lower(), s.inf)
+		ubs[i] = toSolverInf(v.Upper(), s.inf)
+		types[i] = byte(v.Type())
+	}
+
+	return lbs, ubs, string(types)
+}
+
+// updateVar sends the bounds and type of a variable of the model to the
+// solver if they have changed since they were last sent. The bounds of
+// reformulated semi-continuous variables are part of their indicator
+// constraints, so such variables can not be changed within a session.
+func (s *Session) updateVar(v *Var) error {
+	// Only the bounds and type are sent to the solver, so renaming a
+	// variable does not change it
+	old := s.vars[v.ID()]
+	if v.Lower() == old.Lower() && v.Upper() == old.Upper() &&
+		v.Type() == old.Type() {
+		return nil
+	}
+
+	if s.relaxed[v.ID()] != nil ||
+		(v.isSemi() && !s.solver.SupportsVarType(byte(v.Type()))) {
+		return fmt.Errorf(
+			"cannot change semi-continuous variable %d in a session with a "+
+				"solver that does not support it natively", v.ID(),
+		)
+	}
+
+	col := s.cols[v.ID()]
+	if v.Lower() != old.Lower() || v.Upper() != old.Upper() {
+		s.solver.SetVarBounds(
+			col, toSolverInf(v.Lower(), s.inf), toSolverInf(v.Upper(), s.inf),
+		)
+	}
+
+	if v.Type() != old.Type() {
+		s.solver.SetVarType(col, byte(v.Type()))
+	}
+
+	s.vars[v.ID()] = *v
+	return nil
+}
+
+// addVar assigns the next column to the variable and queues it to be sent to
+// the solver.
+func (s *Session) addVar(v *Var) {
+	s.cols[v.ID()] = len(s.colIDs)
+	s.colIDs = append(s.colIDs, v.ID())
+	s.pendingVars = append(s.pendingVars, v)
+}
+
+// delVar deletes the column of the variable with the given ID from the solver
+// and shifts the columns after it.
+func (s *Session) delVar(id uint64) {
+	col := s.cols[id]
+	s.solver.DelVar(col)
+	s.colIDs = append(s.colIDs[:col], s.colIDs[col+1:]...)
+	delete(s.cols, id)
+
+	for i := col; i < len(s.colIDs); i++ {
+		s.cols[s.colIDs[i]] = i
+	}
+}
+
+// delConstr deletes the row of the constraint from the solver and shifts the
+// rows after it.
+func (s *Session) delConstr(constr *Constr) {
+	row := s.rows[constr]
+	s.solver.DelConstr(row)
+	s.rowConstrs = append(s.rowConstrs[:row], s.rowConstrs[row+1:]...)
+	delete(s.rows, constr)
+
+	for i := row; i < len(s.rowConstrs); i++ {
+		s.rows[s.rowConstrs[i]] = i
+	}
+}
+
+// newAuxVar creates an auxiliary variable that only exists in the solver.
+// Auxiliary variable IDs count down from the largest uint64 so they never
+// collide with the IDs of the model's own variables.
+func (s *Session) newAuxVar(lower, upper float64, vtype VarType) *Var {
+	v := &Var{
+		id: math.MaxUint64 - s.numAux, lower: lower, upper: upper, vtype: vtype,
+	}
+	s.numAux++
+	s.addVar(v)
+	return v
+}
+
+// columns returns the solver columns of the variables in the expression.
+func (s *Session) columns(e Expr) ([]uint64, error) {
+	cols := make([]uint64, e.NumVars())
+	for i, id := range e.Vars() {
+		col, ok := s.cols[id]
+		if !ok {
+			return nil, fmt.Errorf(
+				"variable %d is not part of the model, it may have been "+
+					"removed", id,
+			)
+		}
+
+		cols[i] = uint64(col)
+	}
+
+	return cols, nil
+}
+
+// addConstr sends a single constraint to the solver and assigns it the next
+// row
+func (s *Session) addConstr(constr *Constr) error {
+	return s.addConstrs([]*Constr{constr})
+}
+
+// addConstrs sends the constraints to the solver in a single batch and
+// assigns them the next rows
+func (s *Session) addConstrs(constrs []*Constr) error {
+	if len(constrs) == 0 {
+		return nil
+	}
+
+	rowPtr, cols, coeffs, lower, upper, err := s.rowData(constrs)
+	if err != nil {
+		return err
+	}
+
+	s.assignRows(constrs)
+	s.solver.AddConstrs(
+		len(constrs), &rowPtr[0], getColsPtr(cols), getFloatsPtr(coeffs),
+		&lower[0], &upper[0],
+	)
+	return nil
+}
+
+// rowData returns the constraints as rows of the form lower <= a x <= upper
+// in compressed sparse row form with solver columns and infinities
+func (s *Session) rowData(constrs []*Constr) (
+	rowPtr, cols []uint64, coeffs, lower, upper []float64, err error,
+) {
+	rowPtr = make([]uint64, 1, len(constrs)+1)
+	lower = make([]float64, len(constrs))
+	upper = make([]float64, len(constrs))
+	for i, constr := range constrs {
+		lhsCols, err := s.columns(constr.lhs)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+
+		rhsCols, err := s.columns(constr.rhs)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+
+		cols = append(append(cols, lhsCols...), rhsCols...)
+		coeffs = append(coeffs, constr.lhs.Coeffs()...)
+		for _, coeff := range constr.rhs.Coeffs() {
+			coeffs = append(coeffs, -coeff)
+		}
+
+		rowPtr = append(rowPtr, uint64(len(cols)))
+		lower[i], upper[i] = constr.rowBounds()
+		lower[i] = toSolverInf(lower[i], s.inf)
+		upper[i] = toSolverInf(upper[i], s.inf)
+	}
+
+	return rowPtr, cols, coeffs, lower, upper, nil
+}
+
+// assignRows assigns the next rows to the constraints
+func (s *Session) assignRows(constrs []*Constr) {
+	for _, constr := range constrs {
+		s.rows[constr] = len(s.rowConstrs)
+		s.rowConstrs = append(s.rowConstrs, constr)
+	}
+}
+
+// setObjective sends the objective to the solver and keeps a copy of it to
+// detect later changes.
+func (s *Session) setObjective(obj *Objective) error {
+	cols, err := s.columns(obj)
+	if err != nil {
+		return err
+	}
+
+	log.WithField(
+		"num_vars", obj.NumVars(),
+	).Info("Number of variables in objective")
+	s.solver.SetObjective(
+		obj.NumVars(),
+		getCoeffsPtr(obj),
+		getColsPtr(cols),
+		obj.Constant(),
+		int(obj.sense),
+	)
+
+	s.obj = NewObjective(scaleExpr(obj, 1), obj.sense)
+	return nil
+}
+
+// sameObjective returns true if both objectives have the same sense and
+// identical expressions.
+func sameObjective(a, b *Objective) bool {
+	if a.sense != b.sense || a.Constant() != b.Constant() ||
+		a.NumVars() != b.NumVars() {
+		return false
+	}
+
+	aCoeffs, bCoeffs := a.Coeffs(), b.Coeffs()
+	bVars := b.Vars()
+	for i, id := range a.Vars() {
+		if id != bVars[i] || aCoeffs[i] != bCoeffs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getFloatsPtr(vals []float64) *float64 {
+	if len(vals) > 0 {
+		return &vals[0]
+	}
+
+	return nil
+}
+
+func getColsPtr(cols []uint64) *uint64 {
+	if len(cols) > 0 {
+		return &cols[0]
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package goop
+
+import (
+	"testing"
+
+	"github.com/mit-drl/goop/solvers"
+)
+
+// benchmarkModel returns a model with sparse rows of ten variables each
+func benchmarkModel(numVars, numRows int) *Model {
+	m := NewModel()
+	vars := m.AddVarVector(numVars, 0, 1, Continuous)
+	rowPtr := make([]int, numRows+1)
+	colIdx := make([]int, 0, 10*numRows)
+	vals := make([]float64, 0, 10*numRows)
+	senses := make([]ConstrSense, numRows)
+	rhs := make([]float64, numRows)
+	for i := 0; i < numRows; i++ {
+		for k := 0; k < 10; k++ {
+			colIdx = append(colIdx, (i*7+k*13)%numVars)
+			vals = append(vals, float64(k+1))
+		}
+
+		rowPtr[i+1] = len(vals)
+		senses[i] = SenseLessThanEqual
+		rhs[i] = 10
+	}
+
+	if _, err := m.AddConstrsCSR(
+		vars, rowPtr, colIdx, vals, senses, rhs,
+	); err != nil {
+		panic(err)
+	}
+
+	m.SetObjective(SumVars(vars...), SenseMaximize)
+	return m
+}
+
+// BenchmarkLoadModel compares sending a model to the solver in a single
+// LoadModel call with sending one constraint per call
+func BenchmarkLoadModel(b *testing.B) {
+	m := benchmarkModel(1000, 20000)
+
+	b.Run("Bulk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			solver := solvers.NewLPSolveSolver()
+			if err := m.Attach(solver).sync(); err != nil {
+				b.Fatal(err)
+			}
+
+			solvers.DeleteSolver(solver)
+		}
+	})
+
+	b.Run("PerConstr", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			solver := solvers.NewLPSolveSolver()
+			s := m.Attach(solver)
+			for _, v := range m.vars {
+				s.addVar(v)
+			}
+
+			if err := s.flush(); err != nil {
+				b.Fatal(err)
+			}
+
+			for _, constr := range m.constrs {
+				if err := s.addConstr(constr); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if err := s.setObjective(m.obj); err != nil {
+				b.Fatal(err)
+			}
+
+			solvers.DeleteSolver(solver)
+		}
+	})
+}
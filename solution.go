@@ -1,42 +1,260 @@
 package goop
 
 import (
+	"errors"
+	"fmt"
+	"math"
+
 	"github.com/mit-drl/goop/solvers"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
 	tinyNum float64 = 0.01
 )
 
+// Errors returned along with the solution when the solver does not find an
+// optimal or feasible solution
+var (
+	ErrInfeasible            = errors.New("model is infeasible")
+	ErrUnbounded             = errors.New("model is unbounded")
+	ErrInfeasibleOrUnbounded = errors.New("model is infeasible or unbounded")
+	ErrNoSolution            = errors.New("solver did not find a solution")
+)
+
+// SolutionStatus represents the outcome of optimizing a model. The encoding
+// matches the status codes used by the solvers package.
+type SolutionStatus int
+
+// Possible solution statuses
+const (
+	StatusOptimal SolutionStatus = iota
+	StatusSuboptimal
+	StatusInfeasible
+	StatusUnbounded
+	StatusInfeasibleOrUnbounded
+	StatusNotSolved
+)
+
+var statusNames = []string{
+	"optimal",
+	"suboptimal",
+	"infeasible",
+	"unbounded",
+	"infeasible_or_unbounded",
+	"not_solved",
+}
+
+func (s SolutionStatus) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return fmt.Sprintf("SolutionStatus(%d)", int(s))
+	}
+
+	return statusNames[s]
+}
+
+// statusByName returns the solution status with the given name
+func statusByName(name string) (SolutionStatus, bool) {
+	for i, statusName := range statusNames {
+		if statusName == name {
+			return SolutionStatus(i), true
+		}
+	}
+
+	return 0, false
+}
+
 // Solution stores the solution of an optimization problem and associated
 // metatdata
 type Solution struct {
-	vals solvers.DoubleVector
+	// vals holds the values of the columns copied from the solver, with the
+	// solver's infinity replaced by math.Inf
+	vals []float64
+
+	// cols maps the ID of every variable in the model to its column in vals
+	cols map[uint64]int
+
+	// fixed holds the values of the variables removed by presolve
+	fixed map[uint64]float64
 
-	// The objective for the solution
+	// vars and names hold the IDs and names of the variables of the model in
+	// order when it was solved, and keyed holds the values of a solution
+	// decoded from JSON without a model keyed by variable name
+	vars  []uint64
+	names []string
+	keyed map[string]float64
+
+	// The objective for the solution. If the model is unbounded, this is
+	// positive or negative infinity depending on the objective sense. For a
+	// hierarchical model, this is the value of the objective optimized first.
 	Objective float64
 
 	// Whether or not the solution is within the optimality threshold
 	Optimal bool
 
+	// The status of the solution, such as optimal, infeasible or unbounded
+	Status SolutionStatus
+
+	// The values of the objectives of a hierarchical model at the solution,
+	// in the order in which the objectives were optimized. For solutions
+	// returned by ParetoFront, these are the values of the given objectives
+	// in the given order. Objectives is nil otherwise.
+	Objectives []float64
+
 	// The optimality gap returned from the solver. For many solvers, this is
 	// the gap between the best possible solution with integer relaxation and
 	// the best integer solution found so far.
 	Gap float64
 }
 
-func newSolution(mipSol solvers.MIPSolution) *Solution {
-	return &Solution{
-		vals:      mipSol.GetValues(),
-		Objective: mipSol.GetObj(),
+// newSolution returns the solution for a solution returned by the solver.
+// The values are copied with a single call and the solver's solution is
+// deleted, which also frees its values, so it must not be used afterwards.
+func newSolution(
+	mipSol solvers.MIPSolution, inf float64, sense ObjSense,
+	cols map[uint64]int,
+) *Solution {
+	defer solvers.DeleteMIPSolution(mipSol)
+
+	vals := make([]float64, mipSol.NumValues())
+	if len(vals) > 0 {
+		mipSol.CopyValues(&vals[0])
+	}
+
+	for i, val := range vals {
+		vals[i] = fromSolverInf(val, inf)
+	}
+
+	sol := &Solution{
+		vals:      vals,
+		cols:      cols,
+		Objective: fromSolverInf(mipSol.GetObj(), inf),
 		Optimal:   mipSol.GetOptimal(),
+		Status:    SolutionStatus(mipSol.GetStatus()),
 		Gap:       mipSol.GetGap(),
 	}
+
+	if sol.Status == StatusUnbounded {
+		sol.Objective = math.Inf(-int(sense))
+	}
+
+	return sol
 }
 
-// Value returns the value assigned to the variable in the solution
+// err returns the error describing why the solution is not feasible or nil if
+// the solver found a feasible solution.
+func (s *Solution) err() error {
+	switch s.Status {
+	case StatusInfeasible:
+		return ErrInfeasible
+	case StatusUnbounded:
+		return ErrUnbounded
+	case StatusInfeasibleOrUnbounded:
+		return ErrInfeasibleOrUnbounded
+	case StatusNotSolved:
+		return ErrNoSolution
+	default:
+		return nil
+	}
+}
+
+// Value returns the value assigned to the variable in the solution. Values
+// at or beyond the solver's infinity are returned as positive or negative
+// infinity.
 func (s *Solution) Value(v *Var) float64 {
-	return s.vals.Get(int(v.ID()))
+	if val, ok := s.value(v.ID()); ok {
+		return val
+	}
+
+	if val, ok := s.keyed[v.Name()]; ok {
+		return val
+	}
+
+	log.WithField("id", v.ID()).Panic("Variable not in solution")
+	return 0
+}
+
+// value returns the value of the variable with the given ID and whether it
+// is part of the solution
+func (s *Solution) value(id uint64) (float64, bool) {
+	if val, ok := s.fixed[id]; ok {
+		return val, true
+	}
+
+	col, ok := s.cols[id]
+	if !ok {
+		return 0, false
+	}
+
+	return s.vals[col], true
+}
+
+// setVars records the variables of the model in order when it was solved
+func (s *Solution) setVars(vs []*Var) {
+	s.vars = make([]uint64, len(vs))
+	s.names = make([]string, len(vs))
+	for i, v := range vs {
+		s.vars[i] = v.ID()
+		s.names[i] = v.name
+	}
+}
+
+// Values returns the values assigned to the variables in the solution
+func (s *Solution) Values(vs []*Var) []float64 {
+	vals := make([]float64, len(vs))
+	for i, v := range vs {
+		vals[i] = s.Value(v)
+	}
+
+	return vals
+}
+
+// ValuesMatrix returns the values assigned to a matrix of variables, such as
+// one created using AddVarMatrix, in the solution
+func (s *Solution) ValuesMatrix(vs [][]*Var) [][]float64 {
+	vals := make([][]float64, len(vs))
+	for i, row := range vs {
+		vals[i] = s.Values(row)
+	}
+
+	return vals
+}
+
+// eval returns the value of the expression at the solution
+func (s *Solution) eval(e Expr) float64 {
+	val := e.Constant()
+	coeffs := e.Coeffs()
+	for i, id := range e.Vars() {
+		varVal, ok := s.value(id)
+		if !ok {
+			log.WithField("id", id).Panic("Variable not in solution")
+		}
+
+		val += coeffs[i] * varVal
+	}
+
+	return val
+}
+
+// setStages sets the values of the objectives of a hierarchical model at the
+// solution and its objective to the value of the objective optimized first
+func (s *Solution) setStages(stages []*prioritizedObjective) {
+	s.setObjectives(stages)
+	if len(stages) > 0 {
+		s.Objective = s.Objectives[0]
+	}
+}
+
+// setObjectives sets the values of the given objectives at the solution
+func (s *Solution) setObjectives(objs []*prioritizedObjective) {
+	if len(objs) == 0 {
+		return
+	}
+
+	s.Objectives = make([]float64, len(objs))
+	for i, obj := range objs {
+		s.Objectives[i] = s.eval(obj)
+	}
 }
 
 // IsOne returns true if the value assigned to the variable is an integer,
@@ -45,3 +263,27 @@ func (s *Solution) Value(v *Var) float64 {
 func (s *Solution) IsOne(v *Var) bool {
 	return (v.Type() == Integer || v.Type() == Binary) && s.Value(v) > tinyNum
 }
+
+// toSolverInf replaces infinite values with the solver's own representation of
+// infinity.
+func toSolverInf(val, inf float64) float64 {
+	if val >= inf {
+		return inf
+	} else if val <= -inf {
+		return -inf
+	}
+
+	return val
+}
+
+// fromSolverInf replaces values at or beyond the solver's representation of
+// infinity with positive or negative infinity.
+func fromSolverInf(val, inf float64) float64 {
+	if val >= inf {
+		return math.Inf(1)
+	} else if val <= -inf {
+		return math.Inf(-1)
+	}
+
+	return val
+}
@@ -0,0 +1,72 @@
+package goop
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/mit-drl/goop/solvers"
+)
+
+// ErrSolverClosed is returned when a model is optimized with a Solver that
+// was closed
+var ErrSolverClosed = errors.New("solver is closed")
+
+// Solver owns a solver created by the solvers package and deletes it once it
+// is closed. It can be passed anywhere a solvers.Solver is expected, and
+// optimizing a model with a closed Solver returns ErrSolverClosed instead of
+// using the deleted solver. A Solver that is no longer referenced is closed
+// by the garbage collector, but closing it explicitly frees the solver's
+// memory and licenses right away. Closing a Solver releases the wrapped
+// solver, so calling its methods directly afterwards panics instead of using
+// deleted memory. A Solver must not be closed while it is optimizing a model,
+// and the wrapped solver must not be deleted using solvers.DeleteSolver.
+type Solver struct {
+	solvers.Solver
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSolver returns a Solver that takes ownership of the given solver, for
+// example NewSolver(solvers.NewLPSolveSolver()).
+func NewSolver(solver solvers.Solver) *Solver {
+	s := &Solver{Solver: solver}
+	runtime.SetFinalizer(s, func(s *Solver) {
+		s.Close()
+	})
+
+	return s
+}
+
+// Close deletes the solver. Closing a solver more than once has no effect.
+func (s *Solver) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	runtime.SetFinalizer(s, nil)
+	solvers.DeleteSolver(s.Solver)
+	s.Solver = nil
+	return nil
+}
+
+// Closed returns true if the solver was closed
+func (s *Solver) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// checkSolver returns ErrSolverClosed if the solver is a closed Solver
+func checkSolver(solver solvers.Solver) error {
+	if s, ok := solver.(*Solver); ok && s.Closed() {
+		return ErrSolverClosed
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@
 #include <vector>
 #include <string>
 #include "solution.hpp"
+#include "progress.hpp"
+#include "params.hpp"
 
 using namespace std;
 
@@ -13,21 +15,88 @@ using namespace std;
 class Solver
 {
     public:
-        Solver() {};
+        Solver() : progress(NULL), terminated(false) {};
         virtual ~Solver() {};
+        // loadModel replaces the contents of the solver with a whole model in
+        // a single call. The columns have the bounds lb and ub and the types
+        // types, the rows are given as for addConstrs and the objective as
+        // for setObjective.
+        virtual void loadModel(
+            int num_vars, double *lb, double *ub, char *types,
+            int num_rows, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+            double *lower, double *upper,
+            int obj_count, double *obj_coeffs, uint64 *obj_var_ids,
+            double obj_constant, int sense) = 0;
         virtual void addVars(
             int count, double *lb, double *ub, char *types) = 0;
-        virtual void addConstr(
-            int lhs_count, double *lhs_coeffs,
-            uint64 *lhs_vars, double lhs_constant,
-            int rhs_count, double *rhs_coeffs,
-            uint64 *rhs_vars, double rhs_constant,
-            char sense) = 0;
+        // addConstrs adds count rows of the form lower <= a x <= upper, where
+        // row i has the coefficients coeffs[k] of the columns var_ids[k] for
+        // row_ptr[i] <= k < row_ptr[i + 1]. Infinite bounds do not apply.
+        virtual void addConstrs(
+            int count, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+            double *lower, double *upper) = 0;
         virtual void setObjective(int count, double *coeffs, uint64 *var_ids,
                 double constant, int sense) = 0;
+        virtual void setVarBounds(int col, double lb, double ub) = 0;
+        virtual void setVarType(int col, char vtype) = 0;
+        virtual void delVar(int col) = 0;
+        virtual void delConstr(int row) = 0;
         virtual void showLog(bool shouldShow) = 0;
         virtual void setTimeLimit(double timeLimit) = 0;
         virtual MIPSolution optimize() = 0;
+        virtual double infinity()
+        {
+            return 1e30;
+        };
+        virtual bool supportsVarType(char vtype)
+        {
+            return vtype == 'C' || vtype == 'B' || vtype == 'I';
+        };
+        virtual bool supportsParam(int param)
+        {
+            return false;
+        };
+        virtual void setParam(int param, double value) {};
+        virtual bool supportsPool()
+        {
+            return false;
+        };
+        virtual void setPoolSize(int count, double gap) {};
+        // resetPool restores the parameters changed by setPoolSize so that
+        // later solves only search for a single solution again
+        virtual void resetPool() {};
+        virtual int getPoolCount()
+        {
+            return 0;
+        };
+        virtual MIPSolution getPoolSolution(int i)
+        {
+            return MIPSolution();
+        };
+        void setProgressCallback(ProgressCallback *cb)
+        {
+            progress = cb;
+        };
+        void clearProgressCallback()
+        {
+            progress = NULL;
+        };
+        // terminate may be called from another thread to stop a running
+        // solve as soon as possible. It stays in effect until cleared.
+        virtual void terminate()
+        {
+            terminated = true;
+        };
+        void clearTerminate()
+        {
+            terminated = false;
+        };
+    protected:
+        volatile bool terminated;
+
+        // progress is owned by the caller and must outlive every optimize
+        // call made while it is set
+        ProgressCallback *progress;
 };
 
 #endif
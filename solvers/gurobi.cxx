@@ -1,17 +1,57 @@
 
 #include <iostream>
+#include <cmath>
 #include "gurobi_c++.h"
 #include "gurobi.hpp"
 
 using namespace std;
 
-GurobiSolver::GurobiSolver() : env(GRBEnv()), model(env)
+GurobiProgress::GurobiProgress(
+    ProgressCallback **progress, volatile bool *terminated) :
+    progress(progress), terminated(terminated)
 {
 }
 
+void GurobiProgress::callback()
+{
+    if (*terminated)
+    {
+        abort();
+        return;
+    }
+
+    if (where != GRB_CB_MIP || *progress == NULL)
+    {
+        return;
+    }
+
+    double incumbent = getDoubleInfo(GRB_CB_MIP_OBJBST);
+    if (getIntInfo(GRB_CB_MIP_SOLCNT) == 0)
+    {
+        incumbent = NAN;
+    }
+
+    bool proceed = (*progress)->onProgress(
+        incumbent,
+        getDoubleInfo(GRB_CB_MIP_OBJBND),
+        getDoubleInfo(GRB_CB_MIP_NODCNT),
+        getDoubleInfo(GRB_CB_RUNTIME));
+
+    if (!proceed)
+    {
+        abort();
+    }
+}
+
+GurobiSolver::GurobiSolver() :
+    numVars(0), env(GRBEnv()), model(env), progressCallback(&progress, &terminated),
+    poolSaved(false), poolSearchMode(0), poolSolutions(0), poolGap(0)
+{
+    model.setCallback(&progressCallback);
+}
+
 GurobiSolver::~GurobiSolver()
 {
-    delete[] vars;
 }
 
 void GurobiSolver::setMethod(int method)
@@ -57,38 +97,97 @@ void GurobiSolver::setTimeLimit(double timeLimit)
     model.getEnv().set(GRB_DoubleParam_TimeLimit, timeLimit);
 }
 
+void GurobiSolver::loadModel(
+        int num_vars, double *lb, double *ub, char *types,
+        int num_rows, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper,
+        int obj_count, double *obj_coeffs, uint64 *obj_var_ids,
+        double obj_constant, int sense)
+{
+    for (size_t i = 0; i < constrs.size(); i++)
+    {
+        model.remove(constrs[i]);
+    }
+
+    for (size_t i = 0; i < vars.size(); i++)
+    {
+        model.remove(vars[i]);
+    }
+
+    constrs.clear();
+    vars.clear();
+    numVars = 0;
+
+    addVars(num_vars, lb, ub, types);
+    addConstrs(num_rows, row_ptr, var_ids, coeffs, lower, upper);
+    setObjective(obj_count, obj_coeffs, obj_var_ids, obj_constant, sense);
+    model.update();
+}
+
 void GurobiSolver::addVars(int count, double *lb, double *ub, char *types)
 {
-    vars = model.addVars(lb, ub, NULL, types, NULL, count);
-    numVars = count;
+    GRBVar *newVars = model.addVars(lb, ub, NULL, types, NULL, count);
+    vars.insert(vars.end(), newVars, newVars + count);
+    numVars += count;
+    delete[] newVars;
     model.update();
 }
 
-void GurobiSolver::addConstr(
-        int lhs_count, double *lhs_coeffs, uint64 *lhs_var_ids,
-        double lhs_constant,
-        int rhs_count, double *rhs_coeffs, uint64 *rhs_var_ids,
-        double rhs_constant, char sense)
+void GurobiSolver::setVarBounds(int col, double lb, double ub)
 {
-    GRBLinExpr lhs_expr = lhs_constant;
-    GRBLinExpr rhs_expr = rhs_constant;
-    GRBVar lhs_vars[lhs_count];
-    GRBVar rhs_vars[rhs_count];
+    vars[col].set(GRB_DoubleAttr_LB, lb);
+    vars[col].set(GRB_DoubleAttr_UB, ub);
+}
 
-    for (int i = 0; i < lhs_count; i++)
-    {
-        lhs_vars[i] = vars[lhs_var_ids[i]];
-    }
+void GurobiSolver::setVarType(int col, char vtype)
+{
+    vars[col].set(GRB_CharAttr_VType, vtype);
+}
 
-    for (int i = 0; i < rhs_count; i++)
-    {
-        rhs_vars[i] = vars[rhs_var_ids[i]];
-    }
+void GurobiSolver::delVar(int col)
+{
+    model.remove(vars[col]);
+    vars.erase(vars.begin() + col);
+    numVars--;
+}
+
+void GurobiSolver::delConstr(int row)
+{
+    model.remove(constrs[row]);
+    constrs.erase(constrs.begin() + row);
+}
 
-    lhs_expr.addTerms(lhs_coeffs, lhs_vars, lhs_count);
-    rhs_expr.addTerms(rhs_coeffs, rhs_vars, rhs_count);
-    model.addConstr(lhs_expr, sense, rhs_expr);
+void GurobiSolver::addConstrs(
+        int count, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper)
+{
+    for (int i = 0; i < count; i++)
+    {
+        GRBLinExpr expr = 0;
+        for (uint64 k = row_ptr[i]; k < row_ptr[i + 1]; k++)
+        {
+            expr += coeffs[k] * vars[var_ids[k]];
+        }
 
+        if (lower[i] <= -GRB_INFINITY)
+        {
+            constrs.push_back(
+                model.addConstr(expr, GRB_LESS_EQUAL, upper[i]));
+        }
+        else if (upper[i] >= GRB_INFINITY)
+        {
+            constrs.push_back(
+                model.addConstr(expr, GRB_GREATER_EQUAL, lower[i]));
+        }
+        else if (lower[i] == upper[i])
+        {
+            constrs.push_back(model.addConstr(expr, GRB_EQUAL, upper[i]));
+        }
+        else
+        {
+            constrs.push_back(model.addRange(expr, lower[i], upper[i]));
+        }
+    }
 }
 
 void GurobiSolver::setObjective(int count, double *coeffs, uint64 *var_ids,
@@ -117,19 +216,68 @@ MIPSolution GurobiSolver::optimize()
         model.optimize();
         sol.values.resize(numVars);
 
-        for (int i = 0; i < numVars; i++)
+        int status = model.get(GRB_IntAttr_Status);
+
+        // Disambiguate infeasible and unbounded models by re-solving without
+        // dual reductions
+        if (status == GRB_INF_OR_UNBD)
         {
-            sol.values.at(i) = vars[i].get(GRB_DoubleAttr_X);
+            model.set(GRB_IntParam_DualReductions, 0);
+            model.optimize();
+            model.set(GRB_IntParam_DualReductions, 1);
+            status = model.get(GRB_IntAttr_Status);
         }
 
-        sol.obj = model.get(GRB_DoubleAttr_ObjVal);
-        sol.gap = model.get(GRB_DoubleAttr_MIPGap);
-        sol.optimal = model.get(GRB_IntAttr_Status) == GRB_OPTIMAL;
+        bool hasSolution = model.get(GRB_IntAttr_SolCount) > 0;
+        sol.optimal = status == GRB_OPTIMAL;
         sol.errorCode = 0;
         sol.errorMessage = "No error";
+
+        switch (status)
+        {
+            case GRB_OPTIMAL:
+                sol.status = MIP_OPTIMAL;
+                break;
+            case GRB_INFEASIBLE:
+                sol.status = MIP_INFEASIBLE;
+                break;
+            case GRB_UNBOUNDED:
+                sol.status = MIP_UNBOUNDED;
+                break;
+            case GRB_INF_OR_UNBD:
+                sol.status = MIP_INF_OR_UNBD;
+                break;
+            default:
+                sol.status = hasSolution ? MIP_SUBOPTIMAL : MIP_NOT_SOLVED;
+                break;
+        }
+
+        if (hasSolution && numVars > 0)
+        {
+            // All values are queried at once and the returned array is owned
+            // by the caller
+            double *x = model.get(GRB_DoubleAttr_X, &vars[0], numVars);
+            sol.values.assign(x, x + numVars);
+            delete[] x;
+        }
+
+        if (hasSolution)
+        {
+            sol.obj = model.get(GRB_DoubleAttr_ObjVal);
+        }
+
+        if (hasSolution && model.get(GRB_IntAttr_IsMIP))
+        {
+            sol.gap = model.get(GRB_DoubleAttr_MIPGap);
+        }
+        else
+        {
+            sol.gap = 0;
+        }
     }
     catch (GRBException e)
     {
+        sol.status = MIP_NOT_SOLVED;
         sol.errorCode = e.getErrorCode();
         sol.errorMessage = e.getMessage();
         cerr << "Code: " << e.getErrorCode() << endl;
@@ -138,3 +286,143 @@ MIPSolution GurobiSolver::optimize()
 
     return sol;
 }
+
+double GurobiSolver::infinity()
+{
+    return GRB_INFINITY;
+}
+
+bool GurobiSolver::supportsVarType(char vtype)
+{
+    switch (vtype)
+    {
+        case GRB_CONTINUOUS:
+        case GRB_BINARY:
+        case GRB_INTEGER:
+        case GRB_SEMICONT:
+        case GRB_SEMIINT:
+            return true;
+        default:
+            return false;
+    }
+}
+
+bool GurobiSolver::supportsPool()
+{
+    return true;
+}
+
+void GurobiSolver::setPoolSize(int count, double gap)
+{
+    // Save the parameters set by the user once, so that they are restored by
+    // resetPool even if the pool size is set several times
+    if (!poolSaved)
+    {
+        poolSearchMode = model.get(GRB_IntParam_PoolSearchMode);
+        poolSolutions = model.get(GRB_IntParam_PoolSolutions);
+        poolGap = model.get(GRB_DoubleParam_PoolGap);
+        poolSaved = true;
+    }
+
+    // Search systematically for the count best solutions
+    model.set(GRB_IntParam_PoolSearchMode, 2);
+    model.set(GRB_IntParam_PoolSolutions, count);
+    model.set(GRB_DoubleParam_PoolGap, gap > 0 ? gap : GRB_INFINITY);
+}
+
+void GurobiSolver::resetPool()
+{
+    if (!poolSaved)
+    {
+        return;
+    }
+
+    model.set(GRB_IntParam_PoolSearchMode, poolSearchMode);
+    model.set(GRB_IntParam_PoolSolutions, poolSolutions);
+    model.set(GRB_DoubleParam_PoolGap, poolGap);
+    poolSaved = false;
+}
+
+int GurobiSolver::getPoolCount()
+{
+    return model.get(GRB_IntAttr_SolCount);
+}
+
+MIPSolution GurobiSolver::getPoolSolution(int i)
+{
+    MIPSolution sol;
+    sol.values.resize(numVars);
+    sol.optimal = false;
+    sol.status = MIP_SUBOPTIMAL;
+    sol.gap = 0;
+    sol.errorCode = 0;
+    sol.errorMessage = "No error";
+
+    try
+    {
+        model.set(GRB_IntParam_SolutionNumber, i);
+
+        if (numVars > 0)
+        {
+            double *x = model.get(GRB_DoubleAttr_Xn, &vars[0], numVars);
+            sol.values.assign(x, x + numVars);
+            delete[] x;
+        }
+
+        sol.obj = model.get(GRB_DoubleAttr_PoolObjVal);
+    }
+    catch (GRBException e)
+    {
+        sol.status = MIP_NOT_SOLVED;
+        sol.errorCode = e.getErrorCode();
+        sol.errorMessage = e.getMessage();
+    }
+
+    return sol;
+}
+
+void GurobiSolver::terminate()
+{
+    Solver::terminate();
+    model.terminate();
+}
+
+bool GurobiSolver::supportsParam(int param)
+{
+    return param >= PARAM_MIP_GAP && param <= PARAM_PRESOLVE;
+}
+
+void GurobiSolver::setParam(int param, double value)
+{
+    switch (param)
+    {
+        case PARAM_MIP_GAP:
+            model.set(GRB_DoubleParam_MIPGap, value);
+            break;
+        case PARAM_MIP_GAP_ABS:
+            model.set(GRB_DoubleParam_MIPGapAbs, value);
+            break;
+        case PARAM_THREADS:
+            model.set(GRB_IntParam_Threads, (int) value);
+            break;
+        case PARAM_FEASIBILITY_TOL:
+            model.set(GRB_DoubleParam_FeasibilityTol, value);
+            break;
+        case PARAM_INT_FEAS_TOL:
+            model.set(GRB_DoubleParam_IntFeasTol, value);
+            break;
+        case PARAM_NODE_LIMIT:
+            model.set(GRB_DoubleParam_NodeLimit, value);
+            break;
+        case PARAM_SOLUTION_LIMIT:
+            model.set(GRB_IntParam_SolutionLimit, (int) value);
+            break;
+        case PARAM_SEED:
+            model.set(GRB_IntParam_Seed, (int) value);
+            break;
+        case PARAM_PRESOLVE:
+            // Presolve is either left to Gurobi or turned off
+            model.set(GRB_IntParam_Presolve, value != 0 ? -1 : 0);
+            break;
+    }
+}
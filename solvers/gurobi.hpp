@@ -4,6 +4,19 @@
 #include "gurobi_c++.h"
 #include "base_solver.hpp"
 
+// GurobiProgress forwards the progress of a MIP solve to the progress callback
+// and aborts the solve once the solver is terminated
+class GurobiProgress : public GRBCallback
+{
+public:
+    GurobiProgress(ProgressCallback **progress, volatile bool *terminated);
+protected:
+    void callback();
+private:
+    ProgressCallback **progress;
+    volatile bool *terminated;
+};
+
 class GurobiSolver : public Solver
 {
 public:
@@ -14,23 +27,46 @@ public:
     void setMIPGapTol(double gap);
     void setConcurrentMIP(int numMips);
     void setThreads(int numThreads);
+    void loadModel(
+        int num_vars, double *lb, double *ub, char *types,
+        int num_rows, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper,
+        int obj_count, double *obj_coeffs, uint64 *obj_var_ids,
+        double obj_constant, int sense);
     void addVars(int count, double *lb, double *ub, char *types);
-    void addConstr(
-        int lhs_count, double *lhs_coeffs,
-        uint64 *lhs_vars, double lhs_constant,
-        int rhs_count, double *rhs_coeffs,
-        uint64 *rhs_vars, double rhs_constant,
-        char sense);
+    void addConstrs(
+        int count, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper);
     void setObjective(int count, double *coeffs, uint64 *var_ids,
             double constant, int sense);
+    void setVarBounds(int col, double lb, double ub);
+    void setVarType(int col, char vtype);
+    void delVar(int col);
+    void delConstr(int row);
     void showLog(bool shouldShow);
     void setTimeLimit(double timeLimit);
     MIPSolution optimize();
+    double infinity();
+    bool supportsVarType(char vtype);
+    bool supportsParam(int param);
+    void setParam(int param, double value);
+    bool supportsPool();
+    void setPoolSize(int count, double gap);
+    void resetPool();
+    int getPoolCount();
+    MIPSolution getPoolSolution(int i);
+    void terminate();
 private:
     int numVars;
     GRBEnv env;
     GRBModel model;
-    GRBVar *vars;
+    vector<GRBVar> vars;
+    vector<GRBConstr> constrs;
+    GurobiProgress progressCallback;
+    bool poolSaved;
+    int poolSearchMode;
+    int poolSolutions;
+    double poolGap;
 };
 
 #endif
@@ -2,11 +2,18 @@
 #include "lp_lib.h"
 #include "lpsolve.hpp"
 #include <iostream>
+#include <cmath>
 
 using namespace std;
 
-LPSolveSolver::LPSolveSolver()
+LPSolveSolver::LPSolveSolver() :
+    lp(NULL), numVars(0), numRows(0), objConstant(0),
+    aborted(false), hasIncumbent(false), lastReport(0)
 {
+    // The model is created right away so that settings can be changed before
+    // any variables are added
+    lp = make_lp(0, 0);
+    set_verbose(lp, NEUTRAL);
 }
 
 LPSolveSolver::~LPSolveSolver()
@@ -34,83 +41,174 @@ void LPSolveSolver::setTimeLimit(double timeLimit)
     set_timeout(lp, timeLimit);
 }
 
-void LPSolveSolver::addVars(int count, double *lb, double *ub, char *types)
+void LPSolveSolver::loadModel(
+    int num_vars, double *lb, double *ub, char *types,
+    int num_rows, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+    double *lower, double *upper,
+    int obj_count, double *obj_coeffs, uint64 *obj_var_ids,
+    double obj_constant, int sense)
 {
-    lp = make_lp(0, count);
-    set_verbose(lp, NEUTRAL);
-    set_add_rowmode(lp, TRUE);
-    numVars = count;
-
-    for (size_t i = 0; i < count; i++)
+    // Settings such as the time limit are kept, but any previous model is
+    // replaced
+    if (numVars > 0 || numRows > 0)
     {
-        set_bounds(lp, i + 1, lb[i], ub[i]);
-        switch (types[i])
-        {
-            case 'I':
-                set_int(lp, i + 1, TRUE);
-                break;
-            case 'B':
-                set_binary(lp, i + 1, TRUE);
-                break;
-        }
+        set_add_rowmode(lp, FALSE);
+        resize_lp(lp, 0, 0);
+        numVars = 0;
+        numRows = 0;
+        ranges.clear();
     }
 
+    addVars(num_vars, lb, ub, types);
+
+    // In row entry mode the objective must be set before the first row is
+    // added, and the rows are then built without reallocating the matrix
+    setObjective(obj_count, obj_coeffs, obj_var_ids, obj_constant, sense);
+    set_add_rowmode(lp, TRUE);
+    addConstrs(num_rows, row_ptr, var_ids, coeffs, lower, upper);
+    set_add_rowmode(lp, FALSE);
 }
 
-void LPSolveSolver::addConstr(
-    int lhs_count, double *lhs_coeffs, uint64 *lhs_var_ids,
-    double lhs_constant,
-    int rhs_count, double *rhs_coeffs, uint64 *rhs_var_ids,
-    double rhs_constant, char sense)
+void LPSolveSolver::addVars(int count, double *lb, double *ub, char *types)
 {
-    int var_count = lhs_count + rhs_count;
-    REAL sparse_row[var_count];
-    int colno[var_count];
+    // Columns can not be added while in row entry mode
+    set_add_rowmode(lp, FALSE);
+    resize_lp(lp, numRows, numVars + count);
 
-    for (size_t i = 0; i < lhs_count; i++)
+    for (int i = 0; i < count; i++)
     {
-        sparse_row[i] = lhs_coeffs[i];
-        colno[i] = (int) lhs_var_ids[i] + 1;
+        int col = numVars + i + 1;
+        add_columnex(lp, 0, NULL, NULL);
+        set_bounds(lp, col, lb[i], ub[i]);
+        setColumnType(col, types[i]);
     }
 
-    for (size_t i = 0; i < rhs_count; i++)
-    {
-        sparse_row[lhs_count + i] = -rhs_coeffs[i];
-        colno[lhs_count + i] = (int) rhs_var_ids[i] + 1;
-    }
+    numVars += count;
+}
 
-    int constr_type;
+void LPSolveSolver::setColumnType(int col, char vtype)
+{
+    set_int(lp, col, FALSE);
+    set_semicont(lp, col, FALSE);
 
-    switch (sense)
+    switch (vtype)
     {
-        case '=':
-            constr_type = EQ;
+        case 'I':
+            set_int(lp, col, TRUE);
             break;
-        case '<':
-            constr_type = LE;
+        case 'B':
+            set_binary(lp, col, TRUE);
             break;
-        case '>':
-            constr_type = GE;
+        case 'S':
+            set_semicont(lp, col, TRUE);
+            break;
+        case 'N':
+            set_int(lp, col, TRUE);
+            set_semicont(lp, col, TRUE);
             break;
     }
+}
+
+void LPSolveSolver::setVarBounds(int col, double lb, double ub)
+{
+    set_bounds(lp, col + 1, lb, ub);
+}
+
+void LPSolveSolver::setVarType(int col, char vtype)
+{
+    setColumnType(col + 1, vtype);
+}
 
-    REAL constant = rhs_constant - lhs_constant;
-    add_constraintex(lp, var_count, sparse_row, colno, constr_type, constant);
+void LPSolveSolver::delVar(int col)
+{
+    set_add_rowmode(lp, FALSE);
+    del_column(lp, col + 1);
+    numVars--;
+}
+
+void LPSolveSolver::delConstr(int row)
+{
+    set_add_rowmode(lp, FALSE);
+    del_constraint(lp, row + 1);
+    numRows--;
+
+    // Pending ranges refer to rows by index, so they shift with the rows
+    for (size_t i = 0; i < ranges.size(); i++)
+    {
+        if (ranges[i].row == row + 1)
+        {
+            ranges.erase(ranges.begin() + i);
+            i--;
+        }
+        else if (ranges[i].row > row + 1)
+        {
+            ranges[i].row--;
+        }
+    }
+}
+
+void LPSolveSolver::addConstrs(
+    int count, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+    double *lower, double *upper)
+{
+    double inf = infinity();
+    vector<REAL> sparse_row;
+    vector<int> colno;
+
+    for (int i = 0; i < count; i++)
+    {
+        int row_count = (int) (row_ptr[i + 1] - row_ptr[i]);
+        sparse_row.resize(row_count + 1);
+        colno.resize(row_count + 1);
+
+        for (int k = 0; k < row_count; k++)
+        {
+            sparse_row[k] = coeffs[row_ptr[i] + k];
+            colno[k] = (int) var_ids[row_ptr[i] + k] + 1;
+        }
+
+        if (lower[i] <= -inf)
+        {
+            add_constraintex(lp, row_count, &sparse_row[0], &colno[0], LE,
+                upper[i]);
+        }
+        else if (upper[i] >= inf)
+        {
+            add_constraintex(lp, row_count, &sparse_row[0], &colno[0], GE,
+                lower[i]);
+        }
+        else if (lower[i] == upper[i])
+        {
+            add_constraintex(lp, row_count, &sparse_row[0], &colno[0], EQ,
+                upper[i]);
+        }
+        else
+        {
+            add_constraintex(lp, row_count, &sparse_row[0], &colno[0], LE,
+                upper[i]);
+            RowRange range = {numRows + 1, upper[i] - lower[i]};
+            ranges.push_back(range);
+        }
+
+        numRows++;
+    }
 }
 
 void LPSolveSolver::setObjective(
     int count, double *coeffs, uint64 *var_ids, double constant, int sense)
 {
-    REAL sparse_row[count];
-    int colno[count];
+    // The objective can only be replaced outside of row entry mode. It is
+    // set densely so coefficients of a previous objective are reset to zero.
+    set_add_rowmode(lp, FALSE);
+    vector<REAL> row(numVars + 1, 0);
 
-    for (size_t i = 0; i < count; i++)
+    for (int i = 0; i < count; i++)
     {
-        sparse_row[i] = coeffs[i];
-        colno[i] = (int) var_ids[i] + 1;
+        row[var_ids[i] + 1] += coeffs[i];
     }
 
-    set_obj_fnex(lp, count, sparse_row, colno);
+    set_obj_fn(lp, &row[0]);
+    objConstant = constant;
 
     switch (sense)
     {
@@ -123,24 +221,165 @@ void LPSolveSolver::setObjective(
     }
 }
 
+// Seconds between two progress reports from the abort callback, which lp_solve
+// calls very frequently
+#define REPORT_INTERVAL 0.1
+
+bool LPSolveSolver::report()
+{
+    if (progress != NULL && !aborted)
+    {
+        double incumbent = NAN;
+        if (hasIncumbent)
+        {
+            incumbent = get_working_objective(lp) + objConstant;
+        }
+
+        aborted = !progress->onProgress(
+            incumbent, NAN, (double) get_total_nodes(lp), time_elapsed(lp));
+        lastReport = time_elapsed(lp);
+    }
+
+    return aborted;
+}
+
+int __WINAPI LPSolveSolver::abortCallback(lprec *lp, void *handle)
+{
+    LPSolveSolver *solver = (LPSolveSolver *) handle;
+
+    if (time_elapsed(lp) - solver->lastReport >= REPORT_INTERVAL)
+    {
+        solver->report();
+    }
+
+    return solver->aborted || solver->terminated ? TRUE : FALSE;
+}
+
+void __WINAPI LPSolveSolver::msgCallback(lprec *lp, void *handle, int msg)
+{
+    LPSolveSolver *solver = (LPSolveSolver *) handle;
+    solver->hasIncumbent = true;
+    solver->report();
+}
+
 MIPSolution LPSolveSolver::optimize()
 {
     MIPSolution sol;
     set_add_rowmode(lp, false);
+
+    aborted = false;
+    hasIncumbent = false;
+    lastReport = 0;
+    put_abortfunc(lp, abortCallback, this);
+    put_msgfunc(lp, msgCallback, this, MSG_MILPFEASIBLE | MSG_MILPBETTER);
+
+    for (size_t i = 0; i < ranges.size(); i++)
+    {
+        set_rh_range(lp, ranges[i].row, ranges[i].delta);
+    }
+
+    ranges.clear();
+
     int res = solve(lp);
-    sol.optimal = res == OPTIMAL;
+    sol.optimal = res == OPTIMAL || res == PRESOLVED;
+    sol.obj = get_objective(lp) + objConstant;
     sol.gap = get_mip_gap(lp, TRUE);
-    sol.errorCode = res;
+    sol.errorCode = 0;
     sol.errorMessage = "No error messages provided for LPSolve";
 
-    sol.values.resize(numVars);
-    REAL vars[numVars];
-    get_variables(lp, vars);
+    switch (res)
+    {
+        case OPTIMAL:
+        case PRESOLVED:
+            sol.status = MIP_OPTIMAL;
+            break;
+        case SUBOPTIMAL:
+            sol.status = MIP_SUBOPTIMAL;
+            break;
+        case INFEASIBLE:
+            sol.status = MIP_INFEASIBLE;
+            break;
+        case UNBOUNDED:
+            sol.status = MIP_UNBOUNDED;
+            break;
+        case TIMEOUT:
+        case USERABORT:
+            // The best solution found so far is kept when stopped early
+            sol.status = hasIncumbent ? MIP_SUBOPTIMAL : MIP_NOT_SOLVED;
+            break;
+        default:
+            sol.status = MIP_NOT_SOLVED;
+            sol.errorCode = res;
+            break;
+    }
 
-    for (size_t i = 0; i < numVars; i++)
+    // The values are read from lp_solve's own array instead of being copied
+    // into a stack array, which overflows for large models
+    sol.values.resize(numVars);
+    REAL *vars;
+    if (numVars > 0 && get_ptr_variables(lp, &vars))
     {
-        sol.values.at(i) = (double) vars[i];
+        for (int i = 0; i < numVars; i++)
+        {
+            sol.values[i] = (double) vars[i];
+        }
     }
 
     return sol;
 }
+
+double LPSolveSolver::infinity()
+{
+    return DEF_INFINITY;
+}
+
+bool LPSolveSolver::supportsVarType(char vtype)
+{
+    switch (vtype)
+    {
+        case 'C':
+        case 'B':
+        case 'I':
+        case 'S':
+        case 'N':
+            return true;
+        default:
+            return false;
+    }
+}
+
+bool LPSolveSolver::supportsParam(int param)
+{
+    // Presolve is not supported since lp_solve deletes presolved rows and
+    // columns from the model, which breaks the row and column indices used to
+    // modify the model between solves
+    switch (param)
+    {
+        case PARAM_MIP_GAP:
+        case PARAM_MIP_GAP_ABS:
+        case PARAM_FEASIBILITY_TOL:
+        case PARAM_INT_FEAS_TOL:
+            return true;
+        default:
+            return false;
+    }
+}
+
+void LPSolveSolver::setParam(int param, double value)
+{
+    switch (param)
+    {
+        case PARAM_MIP_GAP:
+            set_mip_gap(lp, FALSE, value);
+            break;
+        case PARAM_MIP_GAP_ABS:
+            set_mip_gap(lp, TRUE, value);
+            break;
+        case PARAM_FEASIBILITY_TOL:
+            set_epsprimal(lp, value);
+            break;
+        case PARAM_INT_FEAS_TOL:
+            set_epsint(lp, value);
+            break;
+    }
+}
@@ -9,21 +9,51 @@ class LPSolveSolver : public Solver
 public:
     LPSolveSolver();
     ~LPSolveSolver();
+    void loadModel(
+        int num_vars, double *lb, double *ub, char *types,
+        int num_rows, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper,
+        int obj_count, double *obj_coeffs, uint64 *obj_var_ids,
+        double obj_constant, int sense);
     void addVars(int count, double *lb, double *ub, char *types);
-    void addConstr(
-        int lhs_count, double *lhs_coeffs,
-        uint64 *lhs_vars, double lhs_constant,
-        int rhs_count, double *rhs_coeffs,
-        uint64 *rhs_vars, double rhs_constant,
-        char sense);
+    void addConstrs(
+        int count, uint64 *row_ptr, uint64 *var_ids, double *coeffs,
+        double *lower, double *upper);
     void setObjective(int count, double *coeffs, uint64 *var_ids,
             double constant, int sense);
+    void setVarBounds(int col, double lb, double ub);
+    void setVarType(int col, char vtype);
+    void delVar(int col);
+    void delConstr(int row);
     void showLog(bool shouldShow);
     void setTimeLimit(double timeLimit);
     MIPSolution optimize();
+    double infinity();
+    bool supportsVarType(char vtype);
+    bool supportsParam(int param);
+    void setParam(int param, double value);
 private:
+    struct RowRange
+    {
+        int row;
+        double delta;
+    };
+
+    void setColumnType(int col, char vtype);
+    bool report();
+    static int __WINAPI abortCallback(lprec *lp, void *handle);
+    static void __WINAPI msgCallback(lprec *lp, void *handle, int msg);
+
     lprec *lp;
     int numVars;
+    int numRows;
+    double objConstant;
+    vector<RowRange> ranges;
+
+    // State of the current solve shared with the lp_solve callbacks
+    bool aborted;
+    bool hasIncumbent;
+    double lastReport;
 };
 
 #endif
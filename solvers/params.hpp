@@ -0,0 +1,19 @@
+#ifndef GOOP_PARAMS_HPP_
+#define GOOP_PARAMS_HPP_
+
+// Solver independent parameters. These must be kept in sync with Param in
+// the goop package.
+enum SolverParam
+{
+    PARAM_MIP_GAP = 0,
+    PARAM_MIP_GAP_ABS = 1,
+    PARAM_THREADS = 2,
+    PARAM_FEASIBILITY_TOL = 3,
+    PARAM_INT_FEAS_TOL = 4,
+    PARAM_NODE_LIMIT = 5,
+    PARAM_SOLUTION_LIMIT = 6,
+    PARAM_SEED = 7,
+    PARAM_PRESOLVE = 8
+};
+
+#endif
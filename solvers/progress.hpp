@@ -0,0 +1,15 @@
+#ifndef GOOP_PROGRESS_HPP_
+#define GOOP_PROGRESS_HPP_
+
+// ProgressCallback is implemented in Go through a SWIG director and is called
+// by the solvers while they optimize. Values the solver does not know are
+// passed as NaN. Returning false asks the solver to stop as soon as possible.
+class ProgressCallback
+{
+    public:
+        virtual ~ProgressCallback() {};
+        virtual bool onProgress(
+            double incumbent, double bound, double nodes, double elapsed) = 0;
+};
+
+#endif
@@ -1,24 +1,56 @@
 #ifndef GOOP_SOLUTION_HPP
 #define GOOP_SOLUTION_HPP
 
+#include <algorithm>
+#include <string>
 #include <vector>
 
 using namespace std;
 
+// Solver independent status codes of a solution. These must be kept in sync
+// with SolutionStatus in the goop package.
+enum MIPStatus
+{
+    MIP_OPTIMAL = 0,
+    MIP_SUBOPTIMAL = 1,
+    MIP_INFEASIBLE = 2,
+    MIP_UNBOUNDED = 3,
+    MIP_INF_OR_UNBD = 4,
+    MIP_NOT_SOLVED = 5
+};
+
 struct MIPSolution
 {
     vector<double> values;
     double obj;
     double gap;
     bool optimal;
+    int status;
     int errorCode;
     string errorMessage;
 
+    MIPSolution() :
+        obj(0), gap(0), optimal(false), status(MIP_NOT_SOLVED), errorCode(0)
+    {
+    }
+
     double getValue(int i)
     {
         return values.at(i);
     }
 
+    int numValues()
+    {
+        return (int) values.size();
+    }
+
+    // copyValues copies all values to out, which must hold numValues()
+    // doubles, so they can be read with a single call
+    void copyValues(double *out)
+    {
+        copy(values.begin(), values.end(), out);
+    }
+
     ~MIPSolution()
     {
     }
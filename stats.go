@@ -0,0 +1,161 @@
+package goop
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxCoeffRange is the largest ratio between the largest and smallest
+// absolute coefficient that is not flagged as a numerical problem
+const maxCoeffRange float64 = 1e9
+
+// Stats summarizes the size and numerical properties of a model. Magnitudes
+// are taken over nonzero, finite values and are zero if there are none.
+type Stats struct {
+	NumVars        int
+	VarsByType     map[VarType]int
+	NumConstrs     int
+	ConstrsBySense map[ConstrSense]int
+	NumGenConstrs  int
+
+	// NumNonZeros is the number of nonzero coefficients in the constraints
+	NumNonZeros int
+
+	// Magnitudes of the constraint coefficients, right hand sides and
+	// variable bounds
+	MinCoeff, MaxCoeff float64
+	MinRHS, MaxRHS     float64
+	MinBound, MaxBound float64
+
+	// EmptyConstrs holds the indices of constraints without variables
+	EmptyConstrs []int
+
+	// UnusedVars holds the variables that do not appear in any constraint or
+	// objective
+	UnusedVars []*Var
+
+	// InvalidBounds holds the variables whose lower bound exceeds their
+	// upper bound
+	InvalidBounds []*Var
+
+	// Problems describes every problem found in the model
+	Problems []string
+}
+
+// magnitudes tracks the smallest and largest absolute value seen
+type magnitudes struct {
+	min, max float64
+}
+
+func (mag *magnitudes) add(val float64) {
+	val = math.Abs(val)
+	if val == 0 || math.IsInf(val, 0) || math.IsNaN(val) {
+		return
+	}
+
+	if mag.min == 0 || val < mag.min {
+		mag.min = val
+	}
+
+	mag.max = math.Max(mag.max, val)
+}
+
+// Stats computes statistics of the model and flags common numerical problems
+// such as large coefficient ranges, empty constraints, unused variables and
+// invalid bounds.
+func (m *Model) Stats() *Stats {
+	stats := &Stats{
+		NumVars:        len(m.vars),
+		VarsByType:     make(map[VarType]int),
+		NumConstrs:     len(m.constrs),
+		ConstrsBySense: make(map[ConstrSense]int),
+		NumGenConstrs:  len(m.genConstrs),
+	}
+
+	used := make(map[uint64]bool)
+	var coeffs, rhs, bounds magnitudes
+	for i, constr := range m.constrs {
+		stats.ConstrsBySense[constr.sense]++
+		row := newPSRow(constr, i)
+		nonZeros := 0
+		for j, id := range row.vars {
+			if row.coeffs[j] != 0 {
+				nonZeros++
+				coeffs.add(row.coeffs[j])
+				used[id] = true
+			}
+		}
+
+		stats.NumNonZeros += nonZeros
+		if nonZeros == 0 {
+			stats.EmptyConstrs = append(stats.EmptyConstrs, i)
+		}
+
+		rhs.add(row.lo)
+		rhs.add(row.hi)
+	}
+
+	markUsed := func(e Expr) {
+		for _, id := range e.Vars() {
+			used[id] = true
+		}
+	}
+
+	for _, gc := range m.genConstrs {
+		markUsed(gc.result)
+		for _, e := range gc.exprs {
+			markUsed(e)
+		}
+	}
+
+	if m.obj != nil {
+		markUsed(m.obj)
+	}
+
+	for _, obj := range m.objs {
+		markUsed(obj)
+	}
+
+	for _, v := range m.vars {
+		stats.VarsByType[v.Type()]++
+		bounds.add(v.Lower())
+		bounds.add(v.Upper())
+
+		if !used[v.ID()] {
+			stats.UnusedVars = append(stats.UnusedVars, v)
+		}
+
+		if v.Lower() > v.Upper() {
+			stats.InvalidBounds = append(stats.InvalidBounds, v)
+			stats.Problems = append(stats.Problems, fmt.Sprintf(
+				"variable %d has lower bound %v greater than upper bound %v",
+				v.ID(), v.Lower(), v.Upper(),
+			))
+		}
+	}
+
+	stats.MinCoeff, stats.MaxCoeff = coeffs.min, coeffs.max
+	stats.MinRHS, stats.MaxRHS = rhs.min, rhs.max
+	stats.MinBound, stats.MaxBound = bounds.min, bounds.max
+
+	if coeffs.min > 0 && coeffs.max/coeffs.min > maxCoeffRange {
+		stats.Problems = append(stats.Problems, fmt.Sprintf(
+			"coefficient range [%g, %g] exceeds %g",
+			coeffs.min, coeffs.max, maxCoeffRange,
+		))
+	}
+
+	for _, i := range stats.EmptyConstrs {
+		stats.Problems = append(stats.Problems, fmt.Sprintf(
+			"constraint %d has no variables", i,
+		))
+	}
+
+	for _, v := range stats.UnusedVars {
+		stats.Problems = append(stats.Problems, fmt.Sprintf(
+			"variable %d is not used", v.ID(),
+		))
+	}
+
+	return stats
+}
@@ -0,0 +1,55 @@
+package goop_test
+
+import (
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestStats(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1e6, goop.Continuous)
+	y := m.AddBinaryVar()
+	m.AddVar(2, 1, goop.Integer)
+	m.AddConstr(goop.Sum(x.Mult(1e-4), y.Mult(1e6)).LessEq(goop.K(5)))
+	m.AddConstr(goop.Sum(x, x.Mult(-1)).Eq(goop.K(0)))
+	m.SetObjective(x, goop.SenseMaximize)
+
+	stats := m.Stats()
+	if stats.NumVars != 3 || stats.VarsByType[goop.Binary] != 1 {
+		t.Errorf("Var counts mismatch: %v", stats.VarsByType)
+	}
+
+	if stats.ConstrsBySense[goop.SenseLessThanEqual] != 1 ||
+		stats.ConstrsBySense[goop.SenseEqual] != 1 {
+		t.Errorf("Constraint counts mismatch: %v", stats.ConstrsBySense)
+	}
+
+	if stats.NumNonZeros != 2 {
+		t.Errorf("Nonzeros mismatch: %v != 2", stats.NumNonZeros)
+	}
+
+	if stats.MinCoeff != 1e-4 || stats.MaxCoeff != 1e6 {
+		t.Errorf(
+			"Coefficient range mismatch: [%v, %v] != [1e-4, 1e6]",
+			stats.MinCoeff, stats.MaxCoeff,
+		)
+	}
+
+	// The coefficient range, the constraint whose terms cancel out, the
+	// unused variable and its invalid bounds are all flagged
+	if len(stats.EmptyConstrs) != 1 || stats.EmptyConstrs[0] != 1 {
+		t.Errorf("Empty constraints mismatch: %v != [1]", stats.EmptyConstrs)
+	}
+
+	if len(stats.UnusedVars) != 1 || len(stats.InvalidBounds) != 1 {
+		t.Errorf(
+			"Flagged variables mismatch: %v unused, %v invalid",
+			len(stats.UnusedVars), len(stats.InvalidBounds),
+		)
+	}
+
+	if len(stats.Problems) != 4 {
+		t.Errorf("Problems mismatch: %v", stats.Problems)
+	}
+}
@@ -0,0 +1,226 @@
+package goop
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// All is a wildcard index for VarTensor.Slice that keeps every index of a
+// dimension
+const All = -1
+
+// VarTensor is an n-dimensional array of variables. Tensors returned by Slice
+// are views that share variables with the tensor they were sliced from.
+type VarTensor struct {
+	shape   []int
+	strides []int
+	offset  int
+	vars    []*Var
+}
+
+// AddVarTensor adds an n-dimensional array of variables of a given type with
+// lower and upper value limits to the model and returns it. The variables are
+// created in row-major order.
+func (m *Model) AddVarTensor(
+	shape []int, lower, upper float64, vtype VarType,
+) *VarTensor {
+	for _, n := range shape {
+		if n < 0 {
+			log.WithField("shape", shape).Panic("Negative tensor dimension")
+		}
+	}
+
+	shape = append([]int{}, shape...)
+	strides := make([]int, len(shape))
+	size := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = size
+		size *= shape[i]
+	}
+
+	return &VarTensor{
+		shape:   shape,
+		strides: strides,
+		vars:    m.AddVarVector(size, lower, upper, vtype),
+	}
+}
+
+// Shape returns the size of every dimension of the tensor
+func (t *VarTensor) Shape() []int {
+	return append([]int{}, t.shape...)
+}
+
+// Size returns the number of variables in the tensor
+func (t *VarTensor) Size() int {
+	return shapeSize(t.shape)
+}
+
+// At returns the variable at the given indices, one per dimension
+func (t *VarTensor) At(idx ...int) *Var {
+	if len(idx) != len(t.shape) {
+		log.WithFields(log.Fields{
+			"num_indices": len(idx),
+			"num_dims":    len(t.shape),
+		}).Panic("Number of indices and dimensions mismatch")
+	}
+
+	pos := t.offset
+	for i, j := range idx {
+		checkIndex(j, t.shape[i])
+		pos += j * t.strides[i]
+	}
+
+	return t.vars[pos]
+}
+
+// Slice returns the view of the tensor at the given indices, one per
+// dimension. A dimension indexed by All is kept, while every other dimension
+// is removed, so t.Slice(All, 2, All) of a tensor with shape [3, 4, 5] has
+// shape [3, 5].
+func (t *VarTensor) Slice(idx ...int) *VarTensor {
+	if len(idx) != len(t.shape) {
+		log.WithFields(log.Fields{
+			"num_indices": len(idx),
+			"num_dims":    len(t.shape),
+		}).Panic("Number of indices and dimensions mismatch")
+	}
+
+	view := &VarTensor{offset: t.offset, vars: t.vars}
+	for i, j := range idx {
+		if j == All {
+			view.shape = append(view.shape, t.shape[i])
+			view.strides = append(view.strides, t.strides[i])
+			continue
+		}
+
+		checkIndex(j, t.shape[i])
+		view.offset += j * t.strides[i]
+	}
+
+	return view
+}
+
+// Vars returns the variables of the tensor in row-major order
+func (t *VarTensor) Vars() []*Var {
+	vs := make([]*Var, 0, t.Size())
+	t.each(func(pos int, _ []int) {
+		vs = append(vs, t.vars[pos])
+	})
+
+	return vs
+}
+
+// SumAxis returns the tensor of the sums of the variables along the given
+// axis, which has the shape of the tensor with the axis removed
+func (t *VarTensor) SumAxis(axis int) *ExprTensor {
+	checkIndex(axis, len(t.shape))
+	shape := append(append([]int{}, t.shape[:axis]...), t.shape[axis+1:]...)
+	sums := &ExprTensor{shape: shape, exprs: make([]Expr, shapeSize(shape))}
+	for i := range sums.exprs {
+		sums.exprs[i] = NewExpr(0)
+	}
+
+	t.each(func(pos int, idx []int) {
+		k := 0
+		for i, j := range idx {
+			if i != axis {
+				k = k*t.shape[i] + j
+			}
+		}
+
+		sums.exprs[k].Plus(t.vars[pos])
+	})
+
+	return sums
+}
+
+// Dot returns the sum of the variables of the tensor multiplied by the
+// coefficients, which are given in row-major order of the tensor's shape
+func (t *VarTensor) Dot(coeffs []float64) Expr {
+	return Dot(t.Vars(), coeffs)
+}
+
+// each calls fn with the position in vars and the indices of every element
+// of the tensor in row-major order
+func (t *VarTensor) each(fn func(pos int, idx []int)) {
+	if t.Size() == 0 {
+		return
+	}
+
+	idx := make([]int, len(t.shape))
+	for {
+		pos := t.offset
+		for i, j := range idx {
+			pos += j * t.strides[i]
+		}
+
+		fn(pos, idx)
+
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < t.shape[i] {
+				break
+			}
+
+			idx[i] = 0
+		}
+
+		if i < 0 {
+			return
+		}
+	}
+}
+
+// ExprTensor is an n-dimensional array of expressions
+type ExprTensor struct {
+	shape []int
+	exprs []Expr
+}
+
+// Shape returns the size of every dimension of the tensor
+func (t *ExprTensor) Shape() []int {
+	return append([]int{}, t.shape...)
+}
+
+// At returns the expression at the given indices, one per dimension
+func (t *ExprTensor) At(idx ...int) Expr {
+	if len(idx) != len(t.shape) {
+		log.WithFields(log.Fields{
+			"num_indices": len(idx),
+			"num_dims":    len(t.shape),
+		}).Panic("Number of indices and dimensions mismatch")
+	}
+
+	pos := 0
+	for i, j := range idx {
+		checkIndex(j, t.shape[i])
+		pos = pos*t.shape[i] + j
+	}
+
+	return t.exprs[pos]
+}
+
+// Exprs returns the expressions of the tensor in row-major order
+func (t *ExprTensor) Exprs() []Expr {
+	return append([]Expr{}, t.exprs...)
+}
+
+// shapeSize returns the number of elements of a tensor with the given shape
+func shapeSize(shape []int) int {
+	size := 1
+	for _, n := range shape {
+		size *= n
+	}
+
+	return size
+}
+
+// checkIndex panics if the index is not within [0, n)
+func checkIndex(idx, n int) {
+	if idx < 0 || idx >= n {
+		log.WithFields(log.Fields{
+			"index": idx,
+			"size":  n,
+		}).Panic("Index out of range")
+	}
+}
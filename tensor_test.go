@@ -0,0 +1,65 @@
+package goop_test
+
+import (
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestVarTensor(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVarTensor([]int{2, 3, 4}, 0, 1, goop.Binary)
+
+	if x.Size() != 24 {
+		t.Errorf("Size mismatch: %v != 24", x.Size())
+	}
+
+	vs := x.Vars()
+	if x.At(1, 2, 3) != vs[23] || x.At(0, 1, 2) != vs[6] {
+		t.Error("At does not use row-major order")
+	}
+
+	slice := x.Slice(goop.All, 1, goop.All)
+	if shape := slice.Shape(); len(shape) != 2 || shape[0] != 2 ||
+		shape[1] != 4 {
+		t.Errorf("Shape mismatch: %v != [2 4]", shape)
+	}
+
+	if slice.At(1, 2) != x.At(1, 1, 2) {
+		t.Error("Slice does not share variables")
+	}
+
+	if row := x.Slice(1, 0, goop.All).Slice(goop.All); row.At(3) != vs[15] {
+		t.Error("Nested slice mismatch")
+	}
+
+	sums := x.SumAxis(1)
+	if shape := sums.Shape(); len(shape) != 2 || shape[0] != 2 ||
+		shape[1] != 4 {
+		t.Errorf("Shape mismatch: %v != [2 4]", shape)
+	}
+
+	sum := sums.At(1, 2)
+	expected := []uint64{x.At(1, 0, 2).ID(), x.At(1, 1, 2).ID(),
+		x.At(1, 2, 2).ID()}
+	vars := sum.Vars()
+	if len(vars) != len(expected) {
+		t.Fatalf("NumVars mismatch: %v != %v", len(vars), len(expected))
+	}
+
+	for i := range vars {
+		if vars[i] != expected[i] {
+			t.Errorf("Var mismatch: %v != %v", vars[i], expected[i])
+		}
+	}
+
+	coeffs := make([]float64, 8)
+	for i := range coeffs {
+		coeffs[i] = float64(i)
+	}
+
+	dot := slice.Dot(coeffs)
+	if dot.Vars()[5] != x.At(1, 1, 1).ID() || dot.Coeffs()[5] != 5 {
+		t.Error("Dot mismatch")
+	}
+}
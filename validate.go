@@ -0,0 +1,150 @@
+package goop
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValidationError is returned by Validate and lists every problem found in
+// the model
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid model: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the model can be sent to a solver. It returns a
+// *ValidationError listing every variable whose type is unknown, whose bounds
+// are NaN, whose lower bound exceeds its upper bound or, for binary variables,
+// whose bounds are not zero or one, and every constraint, general constraint
+// or objective that uses a variable which is not part of the model, has NaN
+// or infinite coefficients or NaN constants, or whose Vars and Coeffs differ
+// in length. Constraints may have an infinite bound on the side it does not
+// restrict, such as e <= Inf, but not bounds that no value satisfies, such as
+// e <= -Inf or a range whose lower bound exceeds its upper bound. General
+// constraints and objectives must have finite constants.
+// Validate is called by Optimize and Session.Optimize before the model is
+// sent to the solver.
+func (m *Model) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for i, v := range m.vars {
+		switch {
+		case !isVarType(v.Type()):
+			addProblem(
+				"variable %d (index %d) has unknown type %q",
+				v.ID(), i, byte(v.Type()),
+			)
+		case math.IsNaN(v.Lower()) || math.IsNaN(v.Upper()):
+			addProblem("variable %d (index %d) has NaN bounds", v.ID(), i)
+		case v.Lower() > v.Upper():
+			addProblem(
+				"variable %d (index %d) has lower bound %v greater than "+
+					"upper bound %v", v.ID(), i, v.Lower(), v.Upper(),
+			)
+		case v.Type() == Binary && (!isZeroOrOne(v.Lower()) ||
+			!isZeroOrOne(v.Upper())):
+			addProblem(
+				"binary variable %d (index %d) has bounds [%v, %v] other "+
+					"than zero or one", v.ID(), i, v.Lower(), v.Upper(),
+			)
+		}
+	}
+
+	checkExpr := func(e Expr, what string) {
+		vars, coeffs := e.Vars(), e.Coeffs()
+		if len(vars) != len(coeffs) {
+			addProblem(
+				"%s has %d variables but %d coefficients",
+				what, len(vars), len(coeffs),
+			)
+			return
+		}
+
+		for i, id := range vars {
+			if _, ok := m.byID[id]; !ok {
+				addProblem(
+					"%s uses variable %d which is not part of the model",
+					what, id,
+				)
+			}
+
+			if math.IsNaN(coeffs[i]) || math.IsInf(coeffs[i], 0) {
+				addProblem(
+					"%s has coefficient %v for variable %d",
+					what, coeffs[i], id,
+				)
+			}
+		}
+
+		if math.IsNaN(e.Constant()) {
+			addProblem("%s has constant %v", what, e.Constant())
+		}
+	}
+
+	checkFinite := func(e Expr, what string) {
+		checkExpr(e, what)
+		if math.IsInf(e.Constant(), 0) {
+			addProblem("%s has constant %v", what, e.Constant())
+		}
+	}
+
+	for i, constr := range m.constrs {
+		what := fmt.Sprintf("constraint %d", i)
+		checkExpr(constr.lhs, what)
+		checkExpr(constr.rhs, what)
+
+		lower, upper := constr.rowBounds()
+		switch {
+		case math.IsNaN(lower) || math.IsNaN(upper):
+			addProblem("%s has NaN bounds", what)
+		case lower > upper || math.IsInf(lower, 1) || math.IsInf(upper, -1):
+			addProblem(
+				"%s has bounds [%v, %v] which no value satisfies",
+				what, lower, upper,
+			)
+		}
+	}
+
+	for i, gc := range m.genConstrs {
+		what := fmt.Sprintf("general constraint %d", i)
+		checkFinite(gc.result, what)
+		for _, e := range gc.exprs {
+			checkFinite(e, what)
+		}
+	}
+
+	for i, obj := range m.objs {
+		checkFinite(obj, fmt.Sprintf("objective %d", i))
+	}
+
+	if m.obj != nil {
+		checkFinite(m.obj, "objective")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{problems}
+	}
+
+	return nil
+}
+
+// isVarType returns true if the variable type is one of the known types
+func isVarType(vtype VarType) bool {
+	switch vtype {
+	case Continuous, Binary, Integer, SemiContinuous, SemiInteger:
+		return true
+	}
+
+	return false
+}
+
+func isZeroOrOne(val float64) bool {
+	return val == 0 || val == 1
+}
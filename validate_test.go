@@ -0,0 +1,73 @@
+package goop_test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+func TestValidate(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+	m.AddConstr(x.LessEq(goop.K(1)))
+	m.SetObjective(x, goop.SenseMaximize)
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("Valid model: %v", err)
+	}
+
+	// Variables of other models never share IDs with the variables of the
+	// model
+	other := goop.NewModel()
+	foreign := other.AddVar(0, 1, goop.Continuous)
+	m.AddConstr(goop.Sum(x, foreign).LessEq(goop.K(1)))
+	m.AddConstr(x.Mult(math.NaN()).LessEq(goop.K(1)))
+	m.SetObjective(goop.Sum(x, goop.K(math.Inf(1))), goop.SenseMaximize)
+	b := m.AddBinaryVar()
+	m.SetBounds(b, 0, 2)
+
+	err := m.Validate()
+	verr, ok := err.(*goop.ValidationError)
+	if !ok {
+		t.Fatalf("Error type mismatch: %T != *goop.ValidationError", err)
+	}
+
+	if len(verr.Problems) != 4 {
+		t.Errorf("Problems mismatch: %v", verr.Problems)
+	}
+}
+
+func TestValidateRowBounds(t *testing.T) {
+	m := goop.NewModel()
+	x := m.AddVar(0, 1, goop.Continuous)
+
+	// Infinite bounds on the side a constraint does not restrict are valid
+	m.AddConstr(goop.Between(0, x, goop.Inf))
+	m.AddConstr(x.LessEq(goop.K(goop.Inf)))
+	m.AddConstr(x.GreaterEq(goop.K(-goop.Inf)))
+	if err := m.Validate(); err != nil {
+		t.Errorf("Valid model: %v", err)
+	}
+
+	m.AddConstr(x.LessEq(goop.K(-goop.Inf)))
+	m.AddConstr(goop.Between(3, x, 1))
+	m.AddConstr(x.Eq(goop.K(goop.Inf)))
+	y := m.AddVar(0, 1, goop.VarType('Q'))
+
+	err := m.Validate()
+	verr, ok := err.(*goop.ValidationError)
+	if !ok {
+		t.Fatalf("Error type mismatch: %T != *goop.ValidationError", err)
+	}
+
+	if len(verr.Problems) != 4 {
+		t.Errorf("Problems mismatch: %v", verr.Problems)
+	}
+
+	if !strings.Contains(err.Error(), fmt.Sprintf("variable %d", y.ID())) {
+		t.Errorf("Unknown type of variable %d not reported: %v", y.ID(), err)
+	}
+}
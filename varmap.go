@@ -0,0 +1,82 @@
+package goop
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AddVarMap adds a variable of a given type with lower and upper value limits
+// to the model for every key and returns the variables keyed by it. This
+// allows sparse index sets such as (depot, truck, day) tuples, which are
+// usually struct keys. Every variable is named name[key], where the fields of
+// a struct or array key are separated by commas, so maps should be given
+// different names. The variables are left unnamed if name is empty. The keys
+// must be distinct.
+func AddVarMap[K comparable](
+	m *Model, name string, keys []K, lower, upper float64, vtype VarType,
+) map[K]*Var {
+	// Keys are checked first so that no variables are added for invalid keys
+	vs := make(map[K]*Var, len(keys))
+	for _, key := range keys {
+		if _, ok := vs[key]; ok {
+			log.WithField("key", key).Panic("Duplicate variable map key")
+		}
+
+		vs[key] = nil
+	}
+
+	for _, key := range keys {
+		v := m.AddVar(lower, upper, vtype)
+		if name != "" {
+			v.name = name + "[" + keyName(key) + "]"
+		}
+
+		vs[key] = v
+	}
+
+	return vs
+}
+
+// SumOver returns the sum of the variables whose keys pass the filter. A nil
+// filter sums all variables. The variables are added in the order they were
+// created, so the resulting expression does not depend on map iteration
+// order.
+func SumOver[K comparable](vs map[K]*Var, filter func(K) bool) Expr {
+	selected := make([]*Var, 0, len(vs))
+	for key, v := range vs {
+		if filter == nil || filter(key) {
+			selected = append(selected, v)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].ID() < selected[j].ID()
+	})
+
+	return SumVars(selected...)
+}
+
+// keyName formats a key of a variable map, separating the fields of struct
+// and array keys with commas
+func keyName(key interface{}) string {
+	val := reflect.ValueOf(key)
+	var fields []string
+	switch val.Kind() {
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			fields = append(fields, fmt.Sprint(val.Field(i)))
+		}
+	case reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			fields = append(fields, fmt.Sprint(val.Index(i)))
+		}
+	default:
+		return fmt.Sprint(key)
+	}
+
+	return strings.Join(fields, ",")
+}
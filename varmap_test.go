@@ -0,0 +1,63 @@
+package goop_test
+
+import (
+	"testing"
+
+	"github.com/mit-drl/goop"
+)
+
+type route struct {
+	depot string
+	truck int
+	day   int
+}
+
+func TestAddVarMap(t *testing.T) {
+	m := goop.NewModel()
+	keys := []route{{"north", 1, 1}, {"north", 2, 1}, {"south", 1, 2}}
+	xs := goop.AddVarMap(m, "x", keys, 0, 1, goop.Binary)
+
+	if len(xs) != len(keys) {
+		t.Fatalf("Length mismatch: %v != %v", len(xs), len(keys))
+	}
+
+	if name := xs[keys[1]].Name(); name != "x[north,2,1]" {
+		t.Errorf("Name mismatch: %q != %q", name, "x[north,2,1]")
+	}
+
+	north := goop.SumOver(xs, func(r route) bool { return r.depot == "north" })
+	expected := []uint64{xs[keys[0]].ID(), xs[keys[1]].ID()}
+	if vars := north.Vars(); len(vars) != 2 ||
+		vars[0] != expected[0] || vars[1] != expected[1] {
+		t.Errorf("Vars mismatch: %v != %v", vars, expected)
+	}
+
+	if all := goop.SumOver(xs, nil); all.NumVars() != len(keys) {
+		t.Errorf("NumVars mismatch: %v != %v", all.NumVars(), len(keys))
+	}
+
+	names := goop.AddVarMap(m, "y", []string{"a"}, 0, 1, goop.Continuous)
+	if name := names["a"].Name(); name != "y[a]" {
+		t.Errorf("Name mismatch: %q != %q", name, "y[a]")
+	}
+
+	unnamed := goop.AddVarMap(m, "", []int{1}, 0, 1, goop.Continuous)
+	if name := unnamed[1].Name(); name != "" {
+		t.Errorf("Name mismatch: %q != %q", name, "")
+	}
+}
+
+func TestAddVarMapDuplicateKeys(t *testing.T) {
+	m := goop.NewModel()
+	defer func() {
+		if recover() == nil {
+			t.Error("Duplicate keys did not panic")
+		}
+
+		if n := m.Stats().NumVars; n != 0 {
+			t.Errorf("NumVars mismatch: %v != 0", n)
+		}
+	}()
+
+	goop.AddVarMap(m, "x", []int{1, 2, 1}, 0, 1, goop.Binary)
+}
@@ -1,5 +1,25 @@
 package goop
 
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Inf is positive infinity. It can be used as the lower (as -Inf) or upper
+// bound of a variable that is unbounded in that direction. Infinite bounds
+// are translated to each solver's own representation of infinity.
+var Inf = math.Inf(1)
+
+// lastVarID is the ID of the most recently created variable. Variable IDs are
+// unique across all models, so variables of one model can be told apart from
+// those of another.
+var lastVarID uint64
+
+// newVarID returns a new unique variable ID
+func newVarID() uint64 {
+	return atomic.AddUint64(&lastVarID, 1)
+}
+
 // Var represnts a variable in a optimization problem. The variable is
 // identified with an uint64.
 type Var struct {
@@ -7,6 +27,7 @@ type Var struct {
 	lower float64
 	upper float64
 	vtype VarType
+	name  string
 }
 
 // NumVars returns the number of variables in the expression. For a variable, it
@@ -97,14 +118,39 @@ func (v *Var) Type() VarType {
 	return v.vtype
 }
 
+// Name returns the name of the variable, which is empty unless it was set
+// using Model.SetName
+func (v *Var) Name() string {
+	return v.name
+}
+
+// String returns the name of the variable, or x followed by its ID if it has
+// no name
+func (v *Var) String() string {
+	if v.name != "" {
+		return v.name
+	}
+
+	return defaultVarName(v.id)
+}
+
+// isSemi returns true if the variable is semi-continuous or semi-integer
+func (v *Var) isSemi() bool {
+	return v.vtype == SemiContinuous || v.vtype == SemiInteger
+}
+
 // VarType represents the type of the variable (continuous, binary,
 // integer, etc) and uses Gurobi's encoding.
 type VarType byte
 
 // Multiple common variable types have been included as constants that conform
-// to Gurobi's encoding.
+// to Gurobi's encoding. A semi-continuous variable must be either zero or
+// between its lower and upper bounds, and a semi-integer variable must
+// additionally take an integer value.
 const (
-	Continuous VarType = 'C'
-	Binary             = 'B'
-	Integer            = 'I'
+	Continuous     VarType = 'C'
+	Binary                 = 'B'
+	Integer                = 'I'
+	SemiContinuous         = 'S'
+	SemiInteger            = 'N'
 )